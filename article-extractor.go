@@ -0,0 +1,411 @@
+package main
+
+import (
+	"bytes"
+	"html"
+	"html/template"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+type Heading struct {
+	Level    int
+	ID       string
+	Text     string
+	Line     int
+	Children []*Heading
+}
+
+type ArticleLink struct {
+	Href, Text string
+	Line       int
+}
+
+type ArticleImage struct {
+	Src, Alt string
+	Line     int
+}
+
+type CodeBlock struct {
+	Lang     string // from the "language-xxx" class
+	Class    string // the class attribute of the pre element
+	Code     string // unescaped
+	Line     int    // the line of the pre element
+	CodeLine int    // the line of the first line of Code
+}
+
+// parseArticle extracts the title and other metadata of an article
+// from its HTML content. If the article has no h1 headings, its first
+// h2 heading is used as the title and is modified to an h1 heading.
+//
+// Headings without ids are assigned slug ids, and each non-title
+// heading gets a permalink anchor.
+func parseArticle(article *Article, content []byte) {
+	type element struct {
+		start, end htmlToken
+	}
+	type insertion struct {
+		offset int
+		text   string
+	}
+	var (
+		ids        = collectElementIDs(content)
+		insertions []insertion
+		anchors    []insertion // permalinks, the title one will be excluded
+
+		firstH1, firstH2 *element
+		titleHeading     *Heading
+
+		heading     *Heading
+		headingElem element
+		headingText strings.Builder
+
+		link     *ArticleLink
+		linkText strings.Builder
+
+		code     *CodeBlock
+		codeText strings.Builder
+
+		stack   []*Heading
+		rawText bool
+	)
+
+	z := newHTMLTokenizer(content)
+	for {
+		t, ok := z.Next()
+		if !ok {
+			break
+		}
+
+		switch t.Type {
+		case htmlStartTagToken, htmlSelfClosingTagToken:
+			switch t.Data {
+			case "h1", "h2", "h3", "h4", "h5", "h6":
+				id, _ := t.Attr("id")
+				heading = &Heading{Level: int(t.Data[1] - '0'), ID: id, Line: t.Line}
+				headingElem = element{start: t}
+				headingText.Reset()
+			case "a":
+				if href, ok := t.Attr("href"); ok {
+					link = &ArticleLink{Href: href, Line: t.Line}
+					linkText.Reset()
+				}
+			case "img":
+				src, _ := t.Attr("src")
+				alt, _ := t.Attr("alt")
+				article.Images = append(article.Images, ArticleImage{Src: src, Alt: alt, Line: t.Line})
+			case "pre":
+				class, _ := t.Attr("class")
+				code = &CodeBlock{Class: class, Lang: languageOfClass(class), Line: t.Line}
+				codeText.Reset()
+			case "code":
+				if code != nil && code.Lang == "" {
+					class, _ := t.Attr("class")
+					code.Lang = languageOfClass(class)
+				}
+			case "script", "style":
+				rawText = t.Type == htmlStartTagToken
+			}
+		case htmlEndTagToken:
+			switch t.Data {
+			case "h1", "h2", "h3", "h4", "h5", "h6":
+				if heading == nil || t.Data[1]-'0' != byte(heading.Level) {
+					break
+				}
+				heading.Text = collapseSpaces(headingText.String())
+				headingElem.end = t
+				if heading.ID == "" {
+					heading.ID = uniqueSlug(heading.Text, ids)
+					insertions = append(insertions, insertion{
+						offset: headingElem.start.Start + len("<h1"),
+						text:   ` id="` + html.EscapeString(heading.ID) + `"`,
+					})
+				}
+				anchors = append(anchors, insertion{
+					offset: t.Start,
+					text:   ` <a class="heading-anchor" href="#` + html.EscapeString(heading.ID) + `" aria-label="permalink">#</a>`,
+				})
+				if heading.Level == 1 && firstH1 == nil {
+					firstH1, titleHeading = &element{headingElem.start, t}, heading
+				} else if heading.Level == 2 && firstH2 == nil {
+					firstH2 = &element{headingElem.start, t}
+					if firstH1 == nil {
+						titleHeading = heading
+					}
+				}
+
+				for len(stack) > 0 && stack[len(stack)-1].Level >= heading.Level {
+					stack = stack[:len(stack)-1]
+				}
+				if len(stack) == 0 {
+					article.Headings = append(article.Headings, heading)
+				} else {
+					parent := stack[len(stack)-1]
+					parent.Children = append(parent.Children, heading)
+				}
+				stack = append(stack, heading)
+				heading = nil
+			case "a":
+				if link != nil {
+					link.Text = collapseSpaces(linkText.String())
+					article.Links = append(article.Links, *link)
+					link = nil
+				}
+			case "pre":
+				if code != nil {
+					code.Code = strings.TrimPrefix(codeText.String(), "\n")
+					article.CodeBlocks = append(article.CodeBlocks, *code)
+					code = nil
+				}
+			case "script", "style":
+				rawText = false
+			}
+		case htmlTextToken:
+			if rawText {
+				break
+			}
+			text := html.UnescapeString(t.Data)
+			if code != nil {
+				if code.CodeLine == 0 {
+					code.CodeLine = t.Line
+					if strings.HasPrefix(text, "\n") {
+						code.CodeLine++
+					}
+				}
+				codeText.WriteString(text)
+				break
+			}
+			if heading != nil {
+				headingText.WriteString(text)
+			}
+			if link != nil {
+				linkText.WriteString(text)
+			}
+			article.WordCount += len(strings.Fields(text))
+		}
+	}
+
+	title := firstH1
+	if title == nil && firstH2 != nil {
+		title = firstH2
+		content[title.start.Start+2] = '1' // <h2
+		content[title.end.Start+3] = '1'   // </h2
+		titleHeading.Level = 1
+	}
+	if title != nil {
+		article.Title = template.HTML(content[title.start.End:title.end.Start])
+		article.TitleWithoutTags = titleHeading.Text
+	}
+
+	// Title headings get no permalink anchors.
+	for _, a := range anchors {
+		if title == nil || a.offset != title.end.Start {
+			insertions = append(insertions, a)
+		}
+	}
+	sort.SliceStable(insertions, func(i, j int) bool {
+		return insertions[i].offset < insertions[j].offset
+	})
+	var b strings.Builder
+	b.Grow(len(content) + len(insertions)*64)
+	last := 0
+	for _, ins := range insertions {
+		b.Write(content[last:ins.offset])
+		b.WriteString(ins.text)
+		last = ins.offset
+	}
+	b.Write(content[last:])
+	article.Content = template.HTML(b.String())
+
+	article.TOC = article.Headings
+	if titleHeading != nil {
+		toc := make([]*Heading, 0, len(article.Headings)+len(titleHeading.Children))
+		for _, h := range article.Headings {
+			if h == titleHeading {
+				toc = append(toc, h.Children...)
+			} else {
+				toc = append(toc, h)
+			}
+		}
+		article.TOC = toc
+	}
+}
+
+func collectElementIDs(content []byte) map[string]bool {
+	ids := map[string]bool{}
+	z := newHTMLTokenizer(content)
+	for {
+		t, ok := z.Next()
+		if !ok {
+			return ids
+		}
+		if id, ok := t.Attr("id"); ok {
+			ids[id] = true
+		}
+	}
+}
+
+// uniqueSlug makes a slug from a heading text, which is
+// different from all the ones in ids, then adds it to ids.
+func uniqueSlug(text string, ids map[string]bool) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(text) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+		} else {
+			dash = true
+		}
+	}
+	slug := b.String()
+	if slug == "" {
+		slug = "section"
+	}
+
+	id := slug
+	for n := 2; ids[id]; n++ {
+		id = slug + "-" + strconv.Itoa(n)
+	}
+	ids[id] = true
+	return id
+}
+
+func languageOfClass(class string) string {
+	for _, c := range strings.Fields(class) {
+		if strings.HasPrefix(c, "language-") {
+			return c[len("language-"):]
+		}
+	}
+	return ""
+}
+
+func collapseSpaces(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// extractIndexContent returns the content between the
+// "index starts" and "index ends" comments of a 101.html page.
+func extractIndexContent(content []byte) []byte {
+	start, end := -1, -1
+	z := newHTMLTokenizer(content)
+	for {
+		t, ok := z.Next()
+		if !ok {
+			break
+		}
+		if t.Type != htmlCommentToken {
+			continue
+		}
+		switch strings.TrimSpace(t.Data) {
+		case "index starts (don't remove)":
+			if start < 0 {
+				start = t.End
+			}
+		case "index ends (don't remove)":
+			if start >= 0 {
+				end = t.Start
+			}
+		}
+		if end >= 0 {
+			return content[start:end]
+		}
+	}
+	return nil
+}
+
+type Chapter struct {
+	File, Title string
+}
+
+// extractChapters returns the articles listed with
+// <a class="index" href="..."> links in an index.
+func extractChapters(index []byte) []Chapter {
+	var chapters []Chapter
+	var current *Chapter
+	var title strings.Builder
+	listed := map[string]bool{}
+	z := newHTMLTokenizer(index)
+	for {
+		t, ok := z.Next()
+		if !ok {
+			return chapters
+		}
+		switch {
+		case t.Type == htmlStartTagToken && t.Data == "a" && t.HasClass("index"):
+			href, _ := t.Attr("href")
+			if href != "" && !strings.ContainsAny(href, "#:/") && !listed[href] {
+				listed[href] = true
+				current = &Chapter{File: href}
+				title.Reset()
+			}
+		case t.Type == htmlEndTagToken && t.Data == "a" && current != nil:
+			current.Title = collapseSpaces(title.String())
+			chapters = append(chapters, *current)
+			current = nil
+		case t.Type == htmlTextToken && current != nil:
+			title.WriteString(html.UnescapeString(t.Data))
+		}
+	}
+}
+
+func (article *Article) setChapterNavigation(chapters []Chapter) {
+	for i := range chapters {
+		if chapters[i].File != article.Filename {
+			continue
+		}
+		article.ChapterNumber, article.NumChapters = i+1, len(chapters)
+		if i > 0 {
+			article.PrevChapter = &chapters[i-1]
+		}
+		if i+1 < len(chapters) {
+			article.NextChapter = &chapters[i+1]
+		}
+		return
+	}
+}
+
+// disableArticleLink changes the link to the specified page in an
+// index to a bold text, which can be used as an anchor (id="i-page").
+// Only the links whose href attributes are exactly the page are changed.
+func disableArticleLink(htmlContent template.HTML, page string) template.HTML {
+	content := []byte(htmlContent)
+	z := newHTMLTokenizer(content)
+	var start *htmlToken
+	for {
+		t, ok := z.Next()
+		if !ok {
+			break
+		}
+		if t.Data != "a" {
+			continue
+		}
+		if t.Type == htmlStartTagToken {
+			if href, _ := t.Attr("href"); href == page && start == nil {
+				start = &t
+			}
+		} else if t.Type == htmlEndTagToken && start != nil {
+			var b bytes.Buffer
+			b.Grow(len(content) + 8)
+			b.Write(content[:start.Start])
+			b.WriteString("<b")
+			for _, a := range start.Attrs {
+				if a.Key == "href" {
+					a.Key, a.Val = "id", "i-"+a.Val
+				}
+				b.WriteString(" " + a.Key + `="` + html.EscapeString(a.Val) + `"`)
+			}
+			b.WriteString(">")
+			b.Write(content[start.End:t.Start])
+			b.WriteString("</b>")
+			b.Write(content[t.End:])
+			return template.HTML(b.String())
+		}
+	}
+	return htmlContent
+}
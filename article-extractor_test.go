@@ -0,0 +1,173 @@
+package main
+
+import (
+	"bytes"
+	"html"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// pageFiles returns the article files in pages/ (translations included).
+func pageFiles(t *testing.T) []string {
+	var files []string
+	err := filepath.WalkDir("pages", func(p string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && (d.Name() == "res" || d.Name() == BenchCodeDir) {
+			return filepath.SkipDir
+		}
+		if !d.IsDir() && strings.HasSuffix(p, ".html") {
+			files = append(files, p)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Fatal("no pages found")
+	}
+	return files
+}
+
+// oldArticleTitle is the title extraction used before parseArticle:
+// the first "<h1" ... "</h1>" (or h2) within 256 bytes, with the tags
+// removed.
+func oldArticleTitle(content []byte) (string, bool) {
+	const maxTitleLen = 256
+	split := func(startTag, endTag []byte) (int, int) {
+		j, i := -1, bytes.Index(content, startTag)
+		if i >= 0 {
+			i += len(startTag)
+			s := content[i:]
+			if len(s) > maxTitleLen {
+				s = s[:maxTitleLen]
+			}
+			j = bytes.Index(s, endTag)
+		}
+		if j < 0 {
+			return -1, 0
+		}
+		return i - len(startTag), i + j + len(endTag)
+	}
+	start, end := split([]byte("<h1"), []byte("</h1>"))
+	if start < 0 {
+		start, end = split([]byte("<h2"), []byte("</h2>"))
+	}
+	if start < 0 {
+		return "", false
+	}
+	inTag, s := false, []rune{}
+	for _, r := range html.UnescapeString(string(content[start:end])) {
+		switch {
+		case r == '<' && !inTag, r == '>' && inTag:
+			inTag = !inTag
+		case !inTag:
+			s = append(s, r)
+		}
+	}
+	return string(s), true
+}
+
+// isRedirectPage reports whether a page is redirected, or is
+// a "Moved to" stub linking to the new page.
+func isRedirectPage(file string, content []byte) bool {
+	group, name := filepath.Base(filepath.Dir(file)), filepath.Base(file)
+	_, ok := redirectPages[[2]string{group, name}]
+	return ok || bytes.HasPrefix(bytes.TrimSpace(content), []byte("Moved to "))
+}
+
+func TestParseArticlePages(t *testing.T) {
+	for _, file := range pageFiles(t) {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		oldTitle, hasOldTitle := oldArticleTitle(content)
+
+		var article Article
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					t.Errorf("%s: panic: %v", file, r)
+				}
+			}()
+			parseArticle(&article, append([]byte(nil), content...))
+		}()
+
+		ids := map[string]int{}
+		z := newHTMLTokenizer([]byte(article.Content))
+		for {
+			tok, ok := z.Next()
+			if !ok {
+				break
+			}
+			if id, ok := tok.Attr("id"); ok {
+				ids[id]++
+			}
+		}
+		var checkHeadings func(hs []*Heading)
+		checkHeadings = func(hs []*Heading) {
+			for _, h := range hs {
+				if h.ID == "" {
+					t.Errorf("%s:%d: heading without id", file, h.Line)
+				} else if ids[h.ID] > 1 {
+					t.Errorf("%s:%d: heading id %q is not unique", file, h.Line, h.ID)
+				}
+				checkHeadings(h.Children)
+			}
+		}
+		checkHeadings(article.Headings)
+
+		if isRedirectPage(file, content) {
+			continue
+		}
+		numTitles := strings.Count(string(article.Content), "<h1")
+		if numTitles != 1 || article.TitleWithoutTags == "" {
+			t.Errorf("%s: %d titles, want 1", file, numTitles)
+		}
+		// The old extractor misses titles longer than 256 bytes.
+		if got, want := collapseSpaces(article.TitleWithoutTags), collapseSpaces(oldTitle); hasOldTitle && got != want {
+			t.Errorf("%s: title %q, the old extractor got %q", file, got, want)
+		}
+	}
+}
+
+func TestUniqueSlug(t *testing.T) {
+	ids := map[string]bool{"methods": true}
+	for _, c := range []struct{ text, want string }{
+		{"Value Parts", "value-parts"},
+		{"  Go 1.22: for-loop  semantics ", "go-1-22-for-loop-semantics"},
+		{"Methods", "methods-2"},
+		{"Methods", "methods-3"},
+		{"!!!", "section"},
+		{"!!!", "section-2"},
+		{"Go 类型 Types", "go-类型-types"},
+		{"类型", "类型"},
+	} {
+		if got := uniqueSlug(c.text, ids); got != c.want {
+			t.Errorf("uniqueSlug(%q) = %q, want %q", c.text, got, c.want)
+		}
+	}
+}
+
+func TestParseArticleLines(t *testing.T) {
+	content := "<h1>T</h1>\n<p>See <a href=\"x.html\">\nx</a>.</p>\n<pre class=\"line-numbers\"><code class=\"language-go\">\npackage main\n</code></pre>\n<img src=\"a.png\" alt=\"a\">\n"
+	var article Article
+	parseArticle(&article, []byte(content))
+	if len(article.Links) != 1 || article.Links[0].Line != 2 || article.Links[0].Text != "x" {
+		t.Errorf("got links %+v", article.Links)
+	}
+	if len(article.CodeBlocks) != 1 {
+		t.Fatalf("got %d code blocks, want 1", len(article.CodeBlocks))
+	}
+	if cb := article.CodeBlocks[0]; cb.Line != 4 || cb.CodeLine != 5 || cb.Lang != "go" || cb.Code != "package main\n" {
+		t.Errorf("got code block %+v", cb)
+	}
+	if len(article.Images) != 1 || article.Images[0].Line != 7 {
+		t.Errorf("got images %+v", article.Images)
+	}
+}
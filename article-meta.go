@@ -0,0 +1,231 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"log"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Articles may carry metadata, in a leading comment of their .tmd/.md
+// sources or in a sidecar <name>.meta file (which takes precedence):
+//
+//	<!--
+//	published: 2024-03-01
+//	updated: 2024-06-18
+//	tags: channels, concurrency
+//	authors: Tapir Liu
+//	go: 1.22
+//	related: channel-use-cases.html, ../quizzes/channel-1.html
+//	-->
+//
+// The leading comment must be the first thing in the source file.
+// A sidecar file contains the key-value lines only.
+//
+// Pages in the bugs group may describe toolchain bugs. Each "bug" line
+// (with the Go issue number) starts a new bug, and the following lines
+// specify the code block reproducing the bug (1-based), the outputs
+// without and with the bug (which may be Go-quoted), and optionally
+// the go directive in go.mod ("none" means running without go.mod):
+//
+//	bug: 67190
+//	reproducer: 1
+//	expected: 11true
+//	buggy: 01true
+//	gomod: 1.21
+type ArticleMeta struct {
+	Published    string // 2006-01-02
+	Updated      string // 2006-01-02
+	Tags         []string
+	Authors      []string
+	MinGoVersion string // such as 1.22
+	Related      []RelatedArticle
+	Bugs         []BugMeta
+}
+
+type BugMeta struct {
+	Issue      int
+	Reproducer int // 1-based, in all code blocks of the page
+	Expected   string
+	Buggy      string
+	GoMod      string // blank means the version of the used toolchain
+}
+
+type RelatedArticle struct {
+	Href, Title string
+}
+
+const MetaDateLayout = "2006-01-02"
+
+// Blog posts are named as 2024-03-01-some-title.tmd.
+var datedFilenameRegexp = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2})-`)
+
+var goVersionRegexp = regexp.MustCompile(`^(go)?1(\.\d+){0,2}$`)
+
+func (m *ArticleMeta) IsBlank() bool {
+	return m.Published == "" && m.Updated == "" && len(m.Tags) == 0 && len(m.Authors) == 0 &&
+		m.MinGoVersion == "" && len(m.Related) == 0 && len(m.Bugs) == 0
+}
+
+// loadArticleMeta returns the metadata of an article.
+func loadArticleMeta(lang, group, file string) ArticleMeta {
+	var meta ArticleMeta
+	dir := langGroupDir(lang, group)
+	base := strings.TrimSuffix(file, ".html")
+
+	if source := articleSourceFile(dir, file); source != file {
+		if content, err := loadArticleFile(dir, source); err == nil {
+			if block, ok := leadingCommentBlock(content); ok {
+				parseArticleMeta(&meta, block, path.Join(dir, source))
+			}
+		}
+	}
+	if content, err := loadArticleFile(dir, base+".meta"); err == nil {
+		parseArticleMeta(&meta, content, path.Join(dir, base+".meta"))
+	}
+
+	if meta.Published == "" {
+		if m := datedFilenameRegexp.FindStringSubmatch(file); m != nil {
+			if _, err := time.Parse(MetaDateLayout, m[1]); err == nil {
+				meta.Published = m[1]
+			}
+		}
+	}
+	return meta
+}
+
+// leadingCommentBlock returns the content of the HTML comment
+// at the start of a source file, if the file starts with one.
+func leadingCommentBlock(content []byte) ([]byte, bool) {
+	content = bytes.TrimPrefix(content, []byte("\ufeff")) // BOM
+	content = bytes.TrimLeft(content, " \t\r\n")
+	if !bytes.HasPrefix(content, []byte("<!--")) {
+		return nil, false
+	}
+	end := bytes.Index(content, []byte("-->"))
+	if end < 0 {
+		return nil, false
+	}
+	return content[4:end], true
+}
+
+func parseArticleMeta(meta *ArticleMeta, lines []byte, source string) {
+	scanner := bufio.NewScanner(bytes.NewReader(lines))
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), ":")
+		if !ok {
+			continue
+		}
+		key, value = strings.ToLower(strings.TrimSpace(key)), strings.TrimSpace(value)
+		switch key {
+		case "published", "date", "updated":
+			if _, err := time.Parse(MetaDateLayout, value); err != nil {
+				log.Printf("%s: invalid %s date %q", source, key, value)
+				continue
+			}
+			if key == "updated" {
+				meta.Updated = value
+			} else {
+				meta.Published = value
+			}
+		case "tags":
+			meta.Tags = splitMetaList(value)
+			for i, tag := range meta.Tags {
+				meta.Tags[i] = strings.ToLower(tag)
+			}
+		case "authors", "author":
+			meta.Authors = splitMetaList(value)
+		case "go":
+			if !goVersionRegexp.MatchString(value) {
+				log.Printf("%s: invalid Go version %q", source, value)
+				continue
+			}
+			meta.MinGoVersion = strings.TrimPrefix(value, "go")
+		case "related":
+			meta.Related = nil
+			for _, href := range splitMetaList(value) {
+				meta.Related = append(meta.Related, RelatedArticle{Href: href})
+			}
+		case "bug":
+			issue, err := strconv.Atoi(strings.TrimPrefix(value, "#"))
+			if err != nil {
+				log.Printf("%s: invalid bug issue number %q", source, value)
+				continue
+			}
+			meta.Bugs = append(meta.Bugs, BugMeta{Issue: issue, Reproducer: 1})
+		case "reproducer", "expected", "buggy", "gomod":
+			if len(meta.Bugs) == 0 {
+				log.Printf("%s: %s without a preceding bug line", source, key)
+				continue
+			}
+			bug := &meta.Bugs[len(meta.Bugs)-1]
+			if strings.HasPrefix(value, `"`) {
+				unquoted, err := strconv.Unquote(value)
+				if err != nil {
+					log.Printf("%s: invalid quoted %s %s", source, key, value)
+					continue
+				}
+				value = unquoted
+			}
+			switch key {
+			case "reproducer":
+				if n, err := strconv.Atoi(value); err == nil && n > 0 {
+					bug.Reproducer = n
+				} else {
+					log.Printf("%s: invalid reproducer %q", source, value)
+				}
+			case "expected":
+				bug.Expected = value
+			case "buggy":
+				bug.Buggy = value
+			case "gomod":
+				bug.GoMod = value
+			}
+		}
+	}
+}
+
+func splitMetaList(value string) []string {
+	var list []string
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
+// resolveRelatedArticles fills the titles of the related articles and
+// makes their hrefs absolute (as the ones found by the link graph).
+// The ones which can't be resolved are removed.
+func (go101 *Go101) resolveRelatedArticles(lang, group string, related []RelatedArticle) []RelatedArticle {
+	resolved := related[:0]
+	for _, r := range related {
+		g, file, fragment, ok := go101.resolveArticleHref(group, r.Href)
+		if !ok || file == "" {
+			continue
+		}
+		if to, redirected := redirectPages[[2]string{g, file}]; redirected {
+			g, file = to[0], to[1]
+		}
+		r.Href = langURLPrefix(lang) + articlePath(g, file)
+		if fragment != "" {
+			r.Href += "#" + fragment
+		}
+		a, err := retrieveArticleContent(lang, g, file)
+		if err != nil && lang != DefaultLanguage {
+			a, err = retrieveArticleContent(DefaultLanguage, g, file)
+		}
+		if err != nil {
+			log.Printf("related article %s of group %s is not found", r.Href, group)
+			continue
+		}
+		r.Title = a.TitleWithoutTags
+		resolved = append(resolved, r)
+	}
+	return resolved
+}
@@ -0,0 +1,381 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// BenchResultsFile is the file (in the optimizations group) recording
+// the results of the latest run of "go101 bench".
+const BenchResultsFile = "bench-results.json"
+
+// BenchCodeDir is the folder (in the optimizations group) of the
+// benchmark code. It is a module.
+const BenchCodeDir = "code"
+
+// A BenchReport is the results of running the benchmarks of the
+// optimizations book on a machine.
+type BenchReport struct {
+	GoVersion string `json:"goVersion"`
+	GOOS      string `json:"goos"`
+	GOARCH    string `json:"goarch"`
+	CPU       string `json:"cpu,omitempty"` // reported by go test
+	NumCPU    int    `json:"numCPU"`
+	Count     int    `json:"count"`
+	Benchtime string `json:"benchtime,omitempty"`
+	Date      string `json:"date"` // 2006-01-02
+
+	// Keyed by the paths (relative to the code folder) of the benchmark
+	// units, such as "1-pointer/avoid-indirect-1_test.go" for a file
+	// run alone and "0.1-copy-costs/array-vs-struct" for a package.
+	Units map[string][]BenchResult `json:"units"`
+}
+
+func (br *BenchReport) sameMachine(other *BenchReport) bool {
+	return br.GoVersion == other.GoVersion && br.GOOS == other.GOOS &&
+		br.GOARCH == other.GOARCH && br.CPU == other.CPU && br.NumCPU == other.NumCPU
+}
+
+// A BenchResult is the results of a benchmark function.
+type BenchResult struct {
+	Name  string     `json:"name"`  // such as Benchmark_f
+	Procs int        `json:"procs"` // GOMAXPROCS
+	Runs  []BenchRun `json:"runs"`  // one for each of -count
+}
+
+type BenchRun struct {
+	Iterations  int64   `json:"iterations"`
+	NsPerOp     float64 `json:"nsPerOp"`
+	BytesPerOp  int64   `json:"bytesPerOp"`
+	AllocsPerOp int64   `json:"allocsPerOp"`
+}
+
+// The methods of BenchResult return 0 if there are no runs.
+
+func (br *BenchResult) MeanNsPerOp() float64 {
+	if len(br.Runs) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, run := range br.Runs {
+		sum += run.NsPerOp
+	}
+	return sum / float64(len(br.Runs))
+}
+
+func (br *BenchResult) BytesPerOp() int64 {
+	if len(br.Runs) == 0 {
+		return 0
+	}
+	return br.Runs[len(br.Runs)-1].BytesPerOp
+}
+
+func (br *BenchResult) AllocsPerOp() int64 {
+	if len(br.Runs) == 0 {
+		return 0
+	}
+	return br.Runs[len(br.Runs)-1].AllocsPerOp
+}
+
+// A BenchUnit is a package or a test file run alone.
+type BenchUnit struct {
+	Path string // relative to the code folder
+	File string // blank for packages
+}
+
+func (bu BenchUnit) dir() string {
+	if bu.File == "" {
+		return bu.Path
+	}
+	return path.Dir(bu.Path)
+}
+
+// discoverBenchUnits returns the folders with test files in the code
+// folder. Many folders contain test files written to be run alone
+// (they declare the same names), so the test files of a folder which
+// doesn't build as a package are run one by one.
+func discoverBenchUnits(tc Toolchain, codeDir string) ([]BenchUnit, error) {
+	dirs := map[string][]string{}
+	err := filepath.WalkDir(codeDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.HasSuffix(p, "_test.go") {
+			return err
+		}
+		rel, err := filepath.Rel(codeDir, p)
+		if err != nil {
+			return err
+		}
+		dir, file := path.Split(filepath.ToSlash(rel))
+		dir = strings.TrimSuffix(dir, "/")
+		dirs[dir] = append(dirs[dir], file)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var units []BenchUnit
+	for dir, files := range dirs {
+		build := exec.Command(tc.GoCmd, "test", "-count=1", "-run=^$", ".")
+		build.Dir, build.Env = filepath.Join(codeDir, filepath.FromSlash(dir)), tc.environ()
+		if build.Run() == nil {
+			units = append(units, BenchUnit{Path: dir})
+			continue
+		}
+		for _, file := range files {
+			units = append(units, BenchUnit{Path: path.Join(dir, file), File: file})
+		}
+	}
+	sort.Slice(units, func(i, j int) bool { return units[i].Path < units[j].Path })
+	return units, nil
+}
+
+var benchLineRegexp = regexp.MustCompile(`^(Benchmark\S*?)(?:-(\d+))?\s+(\d+)\s+(.*)$`)
+
+// parseBenchOutput parses the output of "go test -bench -benchmem".
+// The runs of the same benchmark (with -count) are merged.
+func parseBenchOutput(output string) (results []BenchResult, cpu string) {
+	indexes := map[string]int{}
+	for _, line := range strings.Split(output, "\n") {
+		if strings.HasPrefix(line, "cpu: ") {
+			cpu = strings.TrimSpace(strings.TrimPrefix(line, "cpu: "))
+			continue
+		}
+		m := benchLineRegexp.FindStringSubmatch(strings.TrimSpace(line))
+		if m == nil {
+			continue
+		}
+		var run BenchRun
+		run.Iterations, _ = strconv.ParseInt(m[3], 10, 64)
+		fields := strings.Fields(m[4])
+		for i := 0; i+1 < len(fields); i += 2 {
+			switch fields[i+1] {
+			case "ns/op":
+				run.NsPerOp, _ = strconv.ParseFloat(fields[i], 64)
+			case "B/op":
+				run.BytesPerOp, _ = strconv.ParseInt(fields[i], 10, 64)
+			case "allocs/op":
+				run.AllocsPerOp, _ = strconv.ParseInt(fields[i], 10, 64)
+			}
+		}
+		procs := 1 // the suffix is omitted if GOMAXPROCS is 1
+		if m[2] != "" {
+			procs, _ = strconv.Atoi(m[2])
+		}
+		i, ok := indexes[m[1]]
+		if !ok {
+			i = len(results)
+			indexes[m[1]] = i
+			results = append(results, BenchResult{Name: m[1], Procs: procs})
+		}
+		results[i].Runs = append(results[i].Runs, run)
+	}
+	return results, cpu
+}
+
+// runBenchUnit runs the benchmarks of a unit with -benchmem.
+func runBenchUnit(tc Toolchain, codeDir string, unit BenchUnit, count int, benchtime string, timeout time.Duration) ([]BenchResult, string, error) {
+	args := []string{"test", "-run=^$", "-bench=.", "-benchmem", "-count=" + strconv.Itoa(count)}
+	if benchtime != "" {
+		args = append(args, "-benchtime="+benchtime)
+	}
+	if unit.File == "" {
+		args = append(args, ".")
+	} else {
+		args = append(args, unit.File)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, tc.GoCmd, args...)
+	cmd.Dir, cmd.Env = filepath.Join(codeDir, filepath.FromSlash(unit.dir())), tc.environ()
+	output, err := cmd.CombinedOutput()
+	if ctx.Err() == context.DeadlineExceeded {
+		return nil, "", fmt.Errorf("timed out after %s", timeout)
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("%w\n%s", err, output)
+	}
+	results, cpu := parseBenchOutput(string(output))
+	return results, cpu, nil
+}
+
+// loadBenchReport returns the results recorded in the latest run of
+// "go101 bench".
+func loadBenchReport() *BenchReport {
+	data, err := loadArticleFile("optimizations", BenchResultsFile)
+	if err != nil {
+		return nil
+	}
+	var report BenchReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil
+	}
+	return &report
+}
+
+// benchChapterName returns the name of an article or a top folder of
+// the code folder without the number prefix, such as "memory-allocations"
+// for both "0.3-memory-allocations.html" and "0.2-memory-allocations".
+func benchChapterName(name string) string {
+	name = strings.ToLower(strings.TrimSuffix(name, ".html"))
+	if i := strings.IndexByte(name, '-'); i >= 0 && strings.Trim(name[:i], "0123456789.") == "" {
+		name = name[i+1:]
+	}
+	return name
+}
+
+// An ArticleBenchmarks is the benchmark results shown in an article of
+// the optimizations book.
+type ArticleBenchmarks struct {
+	*BenchReport
+	Units []ArticleBenchUnit
+}
+
+type ArticleBenchUnit struct {
+	Path    string
+	Results []BenchResult
+}
+
+// loadArticleBenchmarks returns the results of the benchmarks in the
+// code folder corresponding to an article.
+func loadArticleBenchmarks(file string) *ArticleBenchmarks {
+	report := loadBenchReport()
+	if report == nil {
+		return nil
+	}
+	chapter := benchChapterName(file)
+	ab := &ArticleBenchmarks{BenchReport: report}
+	for p, results := range report.Units {
+		top, _, _ := strings.Cut(p, "/")
+		if benchChapterName(top) != chapter {
+			continue
+		}
+		var ran []BenchResult
+		for _, r := range results {
+			if len(r.Runs) > 0 {
+				ran = append(ran, r)
+			}
+		}
+		if len(ran) > 0 {
+			ab.Units = append(ab.Units, ArticleBenchUnit{p, ran})
+		}
+	}
+	if len(ab.Units) == 0 {
+		return nil
+	}
+	sort.Slice(ab.Units, func(i, j int) bool { return ab.Units[i].Path < ab.Units[j].Path })
+	return ab
+}
+
+func runBench(args []string) int {
+	flags := flag.NewFlagSet("bench", flag.ExitOnError)
+	gorootFlag := flags.String("goroot", "", "the GOROOT of the toolchain to use (default: the one of the go command in PATH)")
+	countFlag := flags.Int("count", 3, "run each benchmark n times")
+	benchtimeFlag := flags.String("benchtime", "", "the -benchtime flag of go test (default 1s)")
+	filterFlag := flags.String("filter", "", "only run the units (such as 1-pointer/avoid-indirect-1_test.go) matching this regexp")
+	timeoutFlag := flags.Duration("timeout", 10*time.Minute, "the time limit of running each unit")
+	dryRunFlag := flags.Bool("n", false, "only print the results, don't update "+BenchResultsFile)
+	jsonFlag := flags.Bool("json", false, "print the results as JSON")
+	flags.Parse(args)
+
+	if !wdIsGo101ProjectRoot {
+		fmt.Fprintln(os.Stderr, "the benchmarks can only be run in the Go 101 project root")
+		return 1
+	}
+	filter, err := regexp.Compile(*filterFlag)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	if *countFlag < 1 {
+		*countFlag = 1
+	}
+	tc, err := selectToolchain(*gorootFlag)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	codeDir := filepath.Join(rootPath, "pages", "optimizations", BenchCodeDir)
+	units, err := discoverBenchUnits(tc, codeDir)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	report := &BenchReport{
+		GoVersion: tc.Version,
+		GOOS:      runtime.GOOS,
+		GOARCH:    runtime.GOARCH,
+		NumCPU:    runtime.NumCPU(),
+		Count:     *countFlag,
+		Benchtime: *benchtimeFlag,
+		Date:      time.Now().Format(MetaDateLayout),
+		Units:     map[string][]BenchResult{},
+	}
+	numFailed := 0
+	for _, unit := range units {
+		if !filter.MatchString(unit.Path) {
+			continue
+		}
+		results, cpu, err := runBenchUnit(tc, codeDir, unit, *countFlag, *benchtimeFlag, *timeoutFlag)
+		if err != nil {
+			numFailed++
+			fmt.Fprintf(os.Stderr, "%s: %s\n", unit.Path, err)
+			continue
+		}
+		if cpu != "" {
+			report.CPU = cpu
+		}
+		report.Units[unit.Path] = results
+		if !*jsonFlag {
+			fmt.Println(unit.Path)
+			for _, r := range results {
+				fmt.Printf("\t%-40s %12.2f ns/op %8d B/op %6d allocs/op\n", r.Name, r.MeanNsPerOp(), r.BytesPerOp(), r.AllocsPerOp())
+			}
+		}
+	}
+	if *jsonFlag {
+		data, _ := json.MarshalIndent(report, "", "\t")
+		fmt.Printf("%s\n", data)
+	}
+	fmt.Fprintf(os.Stderr, "%s (%s/%s, %s): %d units run, %d failed\n", tc.Version,
+		report.GOOS, report.GOARCH, report.CPU, len(report.Units), numFailed)
+
+	if !*dryRunFlag && len(report.Units) > 0 {
+		// The results of the units not run are kept if they
+		// were got on the same machine with the same toolchain.
+		if old := loadBenchReport(); old != nil && old.sameMachine(report) {
+			for p, results := range old.Units {
+				if _, ok := report.Units[p]; !ok {
+					report.Units[p] = results
+				}
+			}
+		}
+		data, err := json.MarshalIndent(report, "", "\t")
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		filename := filepath.Join(rootPath, "pages", "optimizations", BenchResultsFile)
+		if err := os.WriteFile(filename, append(data, '\n'), 0644); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+	}
+	if numFailed > 0 {
+		return 1
+	}
+	return 0
+}
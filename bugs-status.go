@@ -0,0 +1,202 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// The statuses of bugs.
+const (
+	BugReproducible = "reproducible" // the output is the buggy one
+	BugFixed        = "fixed"        // the output is the expected one
+	BugUnknown      = "unknown"      // neither of the stated outputs
+	BugFailed       = "failed"       // the reproducer failed to build or run
+)
+
+// BugsStatusFile is the file (in the bugs group) recording the result
+// of the latest run of "go101 bugs-status".
+const BugsStatusFile = "bugs-status.json"
+
+// A BugStatus is the result of running the reproducer of a bug.
+type BugStatus struct {
+	Issue      int    `json:"issue"`
+	Reproducer int    `json:"reproducer"`
+	GoVersion  string `json:"goVersion"`
+	Status     string `json:"status"`
+	Output     string `json:"output,omitempty"` // set if the status is unknown or failed
+	Checked    string `json:"checked"`          // 2006-01-02
+}
+
+func (bs *BugStatus) IsReproducible() bool { return bs.Status == BugReproducible }
+func (bs *BugStatus) IsFixed() bool        { return bs.Status == BugFixed }
+
+// runReproducer runs the reproducer of a bug with a toolchain and
+// compares the output with the stated expected and buggy outputs.
+func runReproducer(tc Toolchain, bug BugMeta, code string) BugStatus {
+	status := BugStatus{
+		Issue:      bug.Issue,
+		Reproducer: bug.Reproducer,
+		GoVersion:  tc.Version,
+		Checked:    time.Now().Format(MetaDateLayout),
+	}
+	fail := func(output string) BugStatus {
+		status.Status, status.Output = BugFailed, output
+		return status
+	}
+
+	var dir, target string
+	var err error
+	if bug.GoMod == "none" {
+		dir, err = os.MkdirTemp("", "go101-bugs-")
+		target = "main.go"
+	} else {
+		goVersion := bug.GoMod
+		if goVersion == "" {
+			goVersion = tc.LangVersion
+		}
+		dir, err = newScratchModule(goVersion)
+		target = "."
+	}
+	if err != nil {
+		return fail(err.Error())
+	}
+	defer os.RemoveAll(dir)
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte(code), 0644); err != nil {
+		return fail(err.Error())
+	}
+	result, err := buildAndRun(tc, dir, target, 0, time.Minute)
+	switch {
+	case err != nil:
+		return fail(err.Error())
+	case result.Errors != "":
+		return fail(result.Errors)
+	case result.TimedOut:
+		return fail(result.Output + "\n[timed out]")
+	}
+
+	actual := outputLines(result.Output)
+	if ok, _ := matchOutput(outputLines(bug.Buggy), actual); ok {
+		status.Status = BugReproducible
+	} else if ok, _ := matchOutput(outputLines(bug.Expected), actual); ok {
+		status.Status = BugFixed
+	} else {
+		status.Status, status.Output = BugUnknown, result.Output
+	}
+	return status
+}
+
+// checkBugs runs the reproducers of the bugs described in the pages
+// of the bugs group. The results are keyed by page files.
+func (go101 *Go101) checkBugs(tc Toolchain) (map[string][]BugStatus, error) {
+	files, err := listArticleFiles("bugs")
+	if err != nil {
+		return nil, err
+	}
+	statuses := map[string][]BugStatus{}
+	for _, file := range files {
+		if !strings.HasSuffix(file, ".html") {
+			continue
+		}
+		meta := loadArticleMeta(DefaultLanguage, "bugs", file)
+		if len(meta.Bugs) == 0 {
+			continue
+		}
+		article, err := retrieveArticleContent(DefaultLanguage, "bugs", file)
+		if err != nil {
+			return nil, err
+		}
+		for _, bug := range meta.Bugs {
+			if bug.Reproducer > len(article.CodeBlocks) {
+				statuses[file] = append(statuses[file], BugStatus{
+					Issue:      bug.Issue,
+					Reproducer: bug.Reproducer,
+					Status:     BugFailed,
+					Output:     fmt.Sprintf("no code block %d in the page", bug.Reproducer),
+				})
+				continue
+			}
+			code := article.CodeBlocks[bug.Reproducer-1].Code
+			statuses[file] = append(statuses[file], runReproducer(tc, bug, code))
+		}
+	}
+	return statuses, nil
+}
+
+// loadBugStatuses returns the statuses of the bugs described in a page,
+// recorded in the latest run of "go101 bugs-status".
+func loadBugStatuses(file string) []BugStatus {
+	data, err := loadArticleFile("bugs", BugsStatusFile)
+	if err != nil {
+		return nil
+	}
+	var statuses map[string][]BugStatus
+	if err := json.Unmarshal(data, &statuses); err != nil {
+		return nil
+	}
+	return statuses[file]
+}
+
+func runBugsStatus(args []string) int {
+	flags := flag.NewFlagSet("bugs-status", flag.ExitOnError)
+	gorootFlag := flags.String("goroot", "", "the GOROOT of the toolchain to use (default: the one of the go command in PATH)")
+	dryRunFlag := flags.Bool("n", false, "only print the statuses, don't update "+BugsStatusFile)
+	flags.Parse(args)
+
+	tc, err := selectToolchain(*gorootFlag)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	statuses, err := go101.checkBugs(tc)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	files := make([]string, 0, len(statuses))
+	for file := range statuses {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	counts := map[string]int{}
+	for _, file := range files {
+		for _, s := range statuses[file] {
+			counts[s.Status]++
+			fmt.Printf("pages/bugs/%s: golang/go#%d: %s\n", file, s.Issue, s.Status)
+			if s.Output != "" {
+				for _, line := range strings.Split(strings.TrimRight(s.Output, "\n"), "\n") {
+					fmt.Printf("\t%s\n", line)
+				}
+			}
+		}
+	}
+	fmt.Printf("%s: %d reproducible, %d fixed, %d unknown, %d failed\n", tc.Version,
+		counts[BugReproducible], counts[BugFixed], counts[BugUnknown], counts[BugFailed])
+
+	if !*dryRunFlag {
+		if !wdIsGo101ProjectRoot {
+			fmt.Fprintln(os.Stderr, "not in the Go 101 project root, the statuses are not saved")
+			return 1
+		}
+		data, err := json.MarshalIndent(statuses, "", "\t")
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		filename := filepath.Join(rootPath, "pages", "bugs", BugsStatusFile)
+		if err := os.WriteFile(filename, append(data, '\n'), 0644); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+	}
+	if counts[BugUnknown]+counts[BugFailed] > 0 {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// DefaultCanonicalHost is the scheme and host used in the rel="canonical"
+// links of article pages, unless the -canonicalhost flag is specified.
+// Mirrors keep pointing to the main site by default.
+const DefaultCanonicalHost = "https://go101.org"
+
+var canonicalHost = DefaultCanonicalHost
+
+var errBadRequestPath = errors.New("bad request path")
+
+// groupURLPrefix returns the URL path prefix of the pages in a group.
+func groupURLPrefix(group string) string {
+	switch group {
+	case "website":
+		return "/"
+	case "fundamentals":
+		// For history reason, fundamentals pages use "/article/xxx" URLs.
+		return "/article/"
+	}
+	return "/" + group + "/"
+}
+
+// articlePath returns the URL path (without language prefixes) of an article.
+func articlePath(group, file string) string {
+	if file == "index.html" {
+		return groupURLPrefix(group)
+	}
+	return groupURLPrefix(group) + file
+}
+
+func canonicalArticleURL(lang, group, file string) string {
+	return canonicalHost + langURLPrefix(lang) + articlePath(group, file)
+}
+
+// resolveArticleHref resolves an href in an article of the specified
+// group to the group and file of the target article. Links to other
+// websites are not resolved.
+func (go101 *Go101) resolveArticleHref(group, href string) (targetGroup, file, fragment string, ok bool) {
+	if i := strings.IndexByte(href, '#'); i >= 0 {
+		href, fragment = href[:i], href[i+1:]
+	}
+	if i := strings.IndexByte(href, '?'); i >= 0 {
+		href = href[:i]
+	}
+	if strings.Contains(href, ":") || strings.HasPrefix(href, "//") {
+		return "", "", "", false
+	}
+
+	p := href
+	if href == "" {
+		return group, "", fragment, true // in-page link
+	} else if !strings.HasPrefix(href, "/") {
+		p = path.Join(groupURLPrefix(group), href)
+	} else {
+		p = path.Clean(href)
+	}
+	if _, rest := go101.splitLanguagePrefix(p); rest != p {
+		p = rest
+	}
+
+	tokens := strings.SplitN(p[1:], "/", 2)
+	if len(tokens) == 1 {
+		if tokens[0] == "" {
+			return "website", "index.html", fragment, true
+		}
+		return "website", tokens[0], fragment, true
+	}
+	switch urlGroup := tokens[0]; {
+	case urlGroup == "article":
+		return "fundamentals", tokens[1], fragment, true
+	case go101.isArticleURLGroup(urlGroup):
+		return urlGroup, tokens[1], fragment, true
+	}
+	return "", "", "", false
+}
+
+// Only these characters may appear in Go 101 URL paths (only ASCII ones,
+// which is documented in README).
+func isValidPathChar(c byte) bool {
+	switch {
+	case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+		return true
+	}
+	return strings.IndexByte("/-._~@+", c) >= 0
+}
+
+// canonicalizePath returns the canonical form of the request path p.
+// Paths containing traversal segments, NUL bytes or unexpected
+// characters are rejected.
+func (go101 *Go101) canonicalizePath(p string) (string, error) {
+	if p == "" || p[0] != '/' {
+		return "", errBadRequestPath
+	}
+	for i := 0; i < len(p); i++ {
+		if !isValidPathChar(p[i]) {
+			return "", errBadRequestPath
+		}
+	}
+	for _, seg := range strings.Split(p, "/") {
+		if seg == ".." {
+			return "", errBadRequestPath
+		}
+	}
+
+	p = path.Clean(p) // also removes trailing slashes
+	if lang, rest := go101.splitLanguagePrefix(p); lang != "" {
+		rest, err := go101.canonicalizePath(rest)
+		if err != nil {
+			return "", err
+		}
+		if lang == DefaultLanguage {
+			return rest, nil
+		}
+		return "/" + lang + rest, nil
+	}
+	if p == "/index.html" {
+		return "/", nil
+	}
+	if p == "/topics" || p == "/topics/index.html" {
+		return "/topics/", nil
+	}
+	if p == "/me" {
+		return "/me/", nil
+	}
+	if p == "/" || strings.HasPrefix(p, "/static/") {
+		return p, nil
+	}
+
+	tokens := strings.SplitN(p[1:], "/", 2)
+	if len(tokens) == 1 {
+		if go101.isArticleURLGroup(tokens[0]) {
+			return p + "/101.html", nil
+		}
+	} else if strings.HasPrefix(tokens[1], "res/") {
+		return p, nil
+	}
+	if strings.HasSuffix(strings.ToLower(p), ".html") {
+		p = strings.ToLower(p)
+	}
+	return p, nil
+}
+
+// isArticleURLGroup reports whether or not urlGroup is
+// the first URL path segment of the pages in a (non-website) group.
+func (go101 *Go101) isArticleURLGroup(urlGroup string) bool {
+	switch urlGroup {
+	case "article":
+		return true
+	case "website", "fundamentals", "res":
+		return false
+	}
+	_, ok := go101.pageGroups[urlGroup]
+	return ok
+}
+
+// RedirectToCanonicalPath responds with a 301 redirection if the request
+// path is not canonical, or with a 400 error if the path is invalid.
+// It returns whether or not the request has been handled.
+func (go101 *Go101) RedirectToCanonicalPath(w http.ResponseWriter, r *http.Request) bool {
+	canonical, err := go101.canonicalizePath(r.URL.Path)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return true
+	}
+	if canonical == r.URL.Path {
+		return false
+	}
+	if r.URL.RawQuery != "" {
+		canonical += "?" + r.URL.RawQuery
+	}
+	http.Redirect(w, r, canonical, http.StatusMovedPermanently)
+	return true
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"html"
+	"os"
+	"path"
+	"sort"
+	"strings"
+)
+
+type IndexIssue struct {
+	Page    string // relative to the pages folder
+	Line    int    // 0 means unknown
+	Kind    string // "orphan", "missing", "source", "generated" or "title"
+	Problem string
+}
+
+func (issue IndexIssue) String() string {
+	if issue.Line > 0 {
+		return fmt.Sprintf("pages/%s:%d: %s: %s", issue.Page, issue.Line, issue.Kind, issue.Problem)
+	}
+	return fmt.Sprintf("pages/%s: %s: %s", issue.Page, issue.Kind, issue.Problem)
+}
+
+// indexLinks returns the links between the "index starts"
+// and "index ends" comments of a 101.html page.
+func indexLinks(content []byte) []ArticleLink {
+	var links []ArticleLink
+	var link *ArticleLink
+	var text strings.Builder
+	inIndex := false
+	z := newHTMLTokenizer(content)
+	for {
+		t, ok := z.Next()
+		if !ok {
+			return links
+		}
+		switch t.Type {
+		case htmlCommentToken:
+			switch strings.TrimSpace(t.Data) {
+			case "index starts (don't remove)":
+				inIndex = true
+			case "index ends (don't remove)":
+				return links
+			}
+		case htmlStartTagToken:
+			if href, ok := t.Attr("href"); ok && inIndex && t.Data == "a" {
+				link = &ArticleLink{Href: href, Line: t.Line}
+				text.Reset()
+			}
+		case htmlEndTagToken:
+			if link != nil && t.Data == "a" {
+				link.Text = collapseSpaces(text.String())
+				links = append(links, *link)
+				link = nil
+			}
+		case htmlTextToken:
+			if link != nil {
+				text.WriteString(html.UnescapeString(t.Data))
+			}
+		}
+	}
+}
+
+// checkIndexes cross-checks the indexes (101.html pages) of the
+// English groups with the files in the groups.
+func (go101 *Go101) checkIndexes() []IndexIssue {
+	var issues []IndexIssue
+	report := func(page string, line int, kind, format string, args ...any) {
+		issues = append(issues, IndexIssue{page, line, kind, fmt.Sprintf(format, args...)})
+	}
+
+	groups := make([]string, 0, len(go101.pageGroups))
+	for group := range go101.pageGroups {
+		groups = append(groups, group)
+	}
+	sort.Strings(groups)
+
+	// Collect the articles listed in indexes (and the home page).
+	listed := map[[2]string]bool{}
+	for _, group := range groups {
+		file := "101.html"
+		if group == "website" {
+			file = "index.html"
+		}
+		content, err := loadArticleFile(group, file)
+		if err != nil {
+			continue
+		}
+		links := indexLinks(content)
+		if group == "website" {
+			a, _ := retrieveArticleContent(DefaultLanguage, group, file)
+			links = a.Links
+		}
+		for _, link := range links {
+			tgroup, tfile, _, ok := go101.resolveArticleHref(group, link.Href)
+			if !ok || tfile == "" || strings.HasPrefix(tfile, "res/") {
+				continue
+			}
+			key := [2]string{tgroup, tfile}
+			listed[key] = true
+			if to, ok := redirectPages[key]; ok {
+				listed[to] = true
+				continue
+			}
+			if _, ok := go101.pageGroups[tgroup]; ok && !articleFileExists(tgroup, tfile) {
+				report(path.Join(group, file), link.Line, "missing",
+					"%q links to %s, which doesn't exist", link.Text, articlePath(tgroup, tfile))
+			}
+		}
+	}
+
+	titles := map[string][]string{} // title -> pages
+	for _, group := range groups {
+		if group == "website" {
+			continue
+		}
+		files, _ := listArticleFiles(group)
+		existing := map[string]bool{}
+		for _, file := range files {
+			existing[file] = true
+		}
+
+		var numHTML, numGenerated int
+		for _, file := range files {
+			if strings.HasSuffix(file, ".html") {
+				numHTML++
+				if articleSourceFile(group, file) != file {
+					numGenerated++
+				}
+			}
+		}
+
+		for _, file := range files {
+			page := path.Join(group, file)
+			ext := path.Ext(file)
+			base := strings.TrimSuffix(file, ext)
+			switch ext {
+			case ".tmd", ".md":
+				if !existing[base+".html"] {
+					report(page, 0, "source", "no generated %s.html%s", base, similarFile(base+".html", files))
+				}
+				continue
+			case ".html":
+			default:
+				continue
+			}
+
+			// Most HTML files in a group are generated, but this one isn't.
+			if articleSourceFile(group, file) == file && numGenerated*2 > numHTML {
+				report(page, 0, "generated", "no .tmd or .md source%s", similarFile(base+".tmd", files))
+			}
+
+			if !isListedInTopics(file) {
+				continue
+			}
+			if _, redirected := redirectPages[[2]string{group, file}]; !redirected && !listed[[2]string{group, file}] {
+				report(page, 0, "orphan", "not listed in any index")
+			}
+			if article, err := retrieveArticleContent(DefaultLanguage, group, file); err == nil && article.TitleWithoutTags != "" {
+				titles[article.TitleWithoutTags] = append(titles[article.TitleWithoutTags], page)
+			}
+		}
+	}
+
+	for title, pages := range titles {
+		if len(pages) > 1 {
+			for _, page := range pages {
+				report(page, 0, "title", "title %q is shared with %s", title, strings.Join(otherStrings(pages, page), ", "))
+			}
+		}
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool {
+		if issues[i].Page != issues[j].Page {
+			return issues[i].Page < issues[j].Page
+		}
+		return issues[i].Line < issues[j].Line
+	})
+	return issues
+}
+
+// similarFile returns a hint if there is a file whose name is the
+// same as file ignoring cases and extensions.
+func similarFile(file string, files []string) string {
+	base := strings.TrimSuffix(file, path.Ext(file))
+	for _, f := range files {
+		if fb := strings.TrimSuffix(f, path.Ext(f)); fb != base && strings.EqualFold(fb, base) {
+			return fmt.Sprintf(" (%s differs only in case)", f)
+		}
+	}
+	return ""
+}
+
+func otherStrings(list []string, s string) []string {
+	others := make([]string, 0, len(list))
+	for _, v := range list {
+		if v != s {
+			others = append(others, v)
+		}
+	}
+	return others
+}
+
+func runCheckIndex(args []string) int {
+	flags := flag.NewFlagSet("check-index", flag.ExitOnError)
+	flags.Parse(args)
+
+	issues := go101.checkIndexes()
+	for _, issue := range issues {
+		fmt.Fprintln(os.Stdout, issue)
+	}
+	fmt.Fprintf(os.Stdout, "%d issues\n", len(issues))
+	if len(issues) > 0 {
+		return 1
+	}
+	return 0
+}
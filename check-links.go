@@ -0,0 +1,316 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+)
+
+type LinkIssue struct {
+	Page    string // relative to the pages folder
+	Line    int
+	Href    string
+	Problem string
+	IsError bool // otherwise, a warning
+}
+
+func (issue LinkIssue) String() string {
+	kind := "warning"
+	if issue.IsError {
+		kind = "error"
+	}
+	return fmt.Sprintf("pages/%s:%d: %s: %s: %s", issue.Page, issue.Line, kind, issue.Href, issue.Problem)
+}
+
+// A linkChecker checks the links and image sources of articles
+// against the routes served by Go101.ServeHTTP.
+type linkChecker struct {
+	go101   *Go101
+	anchors map[string]map[string]bool // page dir/file -> ids
+	topics  map[string]map[string]bool // lang -> topic page files
+}
+
+// checkLinks checks the articles in all groups and translations.
+// The returned issues are sorted by pages and lines.
+func (go101 *Go101) checkLinks() []LinkIssue {
+	c := &linkChecker{
+		go101:   go101,
+		anchors: map[string]map[string]bool{},
+		topics:  map[string]map[string]bool{},
+	}
+
+	langs := []string{DefaultLanguage}
+	for lang := range go101.translations {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs[1:])
+
+	var issues []LinkIssue
+	for _, lang := range langs {
+		pageGroups := go101.pageGroups
+		if lang != DefaultLanguage {
+			pageGroups = go101.translations[lang]
+		}
+		groups := make([]string, 0, len(pageGroups))
+		for group := range pageGroups {
+			groups = append(groups, group)
+		}
+		sort.Strings(groups)
+
+		for _, group := range groups {
+			files, _ := listArticleFiles(langGroupDir(lang, group))
+			for _, file := range files {
+				if strings.HasSuffix(file, ".html") {
+					issues = append(issues, c.checkArticle(lang, group, file)...)
+				}
+			}
+		}
+	}
+	sort.SliceStable(issues, func(i, j int) bool {
+		if issues[i].Page != issues[j].Page {
+			return issues[i].Page < issues[j].Page
+		}
+		return issues[i].Line < issues[j].Line
+	})
+	return issues
+}
+
+func (c *linkChecker) checkArticle(lang, group, file string) []LinkIssue {
+	article, err := retrieveArticleContent(lang, group, file)
+	page := path.Join(langGroupDir(lang, group), file)
+	if err != nil {
+		return []LinkIssue{{Page: page, Problem: err.Error(), IsError: true}}
+	}
+
+	var issues []LinkIssue
+	report := func(line int, href string, isError bool, format string, args ...any) {
+		issues = append(issues, LinkIssue{
+			Page:    page,
+			Line:    line,
+			Href:    href,
+			Problem: fmt.Sprintf(format, args...),
+			IsError: isError,
+		})
+	}
+
+	for _, link := range article.Links {
+		c.checkHref(lang, group, file, link.Href, link.Line, false, report)
+	}
+	for _, img := range article.Images {
+		c.checkHref(lang, group, file, img.Src, img.Line, true, report)
+	}
+	return issues
+}
+
+func (c *linkChecker) checkHref(lang, group, file, href string, line int, isImage bool,
+	report func(line int, href string, isError bool, format string, args ...any)) {
+	if href == "" || strings.HasPrefix(href, "//") || strings.Contains(strings.SplitN(href, "#", 2)[0], ":") {
+		return // links to other websites
+	}
+
+	target, fragment := href, ""
+	if i := strings.IndexByte(target, '#'); i >= 0 {
+		target, fragment = target[:i], target[i+1:]
+	}
+	if i := strings.IndexByte(target, '?'); i >= 0 {
+		target = target[:i]
+	}
+	if target == "" {
+		if fragment != "" && !c.hasAnchor(lang, group, file, fragment) {
+			report(line, href, true, "missing anchor #%s", fragment)
+		}
+		return
+	}
+
+	p := target
+	if !strings.HasPrefix(p, "/") {
+		p = path.Join(groupURLPrefix(group), p)
+	}
+	if strings.HasSuffix(target, "/") && p != "/" {
+		p += "/"
+	}
+	canonical, err := c.go101.canonicalizePath(p)
+	if err != nil {
+		report(line, href, true, "invalid URL path %s", p)
+		return
+	}
+	if canonical != p {
+		report(line, href, false, "redirected to %s", canonical)
+	}
+
+	// Links in translations without language prefixes are to English pages.
+	targetLang, urlPath := c.go101.splitLanguagePrefix(canonical)
+	if targetLang == "" {
+		targetLang = DefaultLanguage
+		if !strings.HasPrefix(target, "/") {
+			targetLang = lang
+		}
+	}
+
+	tgroup, tfile, ok := c.route(targetLang, urlPath)
+	if !ok {
+		report(line, href, true, "broken link to %s", urlPath)
+		return
+	}
+	if tgroup == "" {
+		return // static files, go-get pages, ...
+	}
+	if isImage && !strings.HasPrefix(tfile, "res/") {
+		report(line, href, false, "image not in a res folder")
+	}
+
+	// Follow the redirections in redirectPages.
+	hops, visited := 0, map[[2]string]bool{}
+	for {
+		to, ok := redirectPages[[2]string{tgroup, tfile}]
+		if !ok {
+			break
+		}
+		if visited[to] {
+			report(line, href, true, "redirect loop at %s", articlePath(tgroup, tfile))
+			return
+		}
+		visited[to] = true
+		tgroup, tfile = to[0], to[1]
+		hops++
+	}
+	switch {
+	case hops > 1:
+		report(line, href, true, "redirect chain (%d hops) to %s", hops, articlePath(tgroup, tfile))
+	case hops == 1:
+		report(line, href, false, "redirected to %s", articlePath(tgroup, tfile))
+	}
+	if hops > 0 && !c.pageExists(targetLang, tgroup, tfile) {
+		report(line, href, true, "redirected to missing page %s", articlePath(tgroup, tfile))
+		return
+	}
+
+	if fragment != "" && strings.HasSuffix(tfile, ".html") && !c.hasAnchor(targetLang, tgroup, tfile, fragment) {
+		report(line, href, true, "missing anchor #%s in %s", fragment, articlePath(tgroup, tfile))
+	}
+}
+
+// route resolves a canonical URL path (without language prefixes) like
+// Go101.ServeHTTP does. The returned group is blank for the pages which
+// are not articles.
+func (c *linkChecker) route(lang, urlPath string) (group, file string, ok bool) {
+	if urlPath == "/" {
+		return "website", "index.html", true
+	}
+	tokens := strings.SplitN(urlPath[1:], "/", 2)
+	if len(tokens) == 1 {
+		name := tokens[0]
+		if i := strings.IndexByte(name, '@'); i > 0 {
+			name = name[:i]
+		}
+		if _, ok := gogetInfos[name]; ok {
+			return "", "", true
+		}
+		return "website", tokens[0], c.pageExists(lang, "website", tokens[0])
+	}
+
+	urlGroup, item := tokens[0], tokens[1]
+	switch urlGroup {
+	case "static":
+		return "", "", staticFileExists(item)
+	case "res":
+		return "website", urlPath[1:], c.pageExists(lang, "website", urlPath[1:])
+	case "topics":
+		return "", "", item == "" || c.topicExists(lang, item)
+	case "article":
+		urlGroup = "fundamentals"
+	default:
+		if _, ok := gogetInfos[urlGroup]; ok {
+			return "", "", true
+		}
+		if !c.go101.isArticleURLGroup(urlGroup) {
+			return "", "", false
+		}
+	}
+	if _, ok := redirectPages[[2]string{urlGroup, item}]; ok {
+		return urlGroup, item, true
+	}
+	return urlGroup, item, c.pageExists(lang, urlGroup, item)
+}
+
+// pageExists reports whether or not a page or a resource file is
+// served. Untranslated ones are served with the English ones.
+func (c *linkChecker) pageExists(lang, group, file string) bool {
+	return articleFileExists(langGroupDir(lang, group), file) ||
+		lang != DefaultLanguage && articleFileExists(group, file)
+}
+
+func (c *linkChecker) topicExists(lang, file string) bool {
+	files := c.topics[lang]
+	if files == nil {
+		files = map[string]bool{}
+		for _, t := range c.go101.collectTopics(lang) {
+			files[t.Slug+".html"] = true
+		}
+		c.topics[lang] = files
+	}
+	return files[file]
+}
+
+func (c *linkChecker) hasAnchor(lang, group, file, id string) bool {
+	dir := langGroupDir(lang, group)
+	if !articleFileExists(dir, file) {
+		dir = group
+	}
+	key := path.Join(dir, file)
+	ids := c.anchors[key]
+	if ids == nil {
+		article, err := retrieveArticleContent(lang, group, file)
+		if err != nil {
+			article, err = retrieveArticleContent(DefaultLanguage, group, file)
+		}
+		if err != nil {
+			return false
+		}
+		ids = collectElementIDs([]byte(article.Content))
+		z := newHTMLTokenizer([]byte(article.Content))
+		for {
+			t, ok := z.Next()
+			if !ok {
+				break
+			}
+			if name, ok := t.Attr("name"); ok && t.Data == "a" {
+				ids[name] = true
+			}
+		}
+		c.anchors[key] = ids
+	}
+	return ids[id]
+}
+
+func runCheckLinks(args []string) int {
+	flags := flag.NewFlagSet("check-links", flag.ExitOnError)
+	quietFlag := flags.Bool("q", false, "only report errors")
+	flags.Parse(args)
+
+	return reportLinkIssues(go101.checkLinks(), *quietFlag)
+}
+
+// reportLinkIssues prints the issues and returns 1 if any of them is an error.
+func reportLinkIssues(issues []LinkIssue, onlyErrors bool) int {
+	var numErrors, numWarnings int
+	for _, issue := range issues {
+		if issue.IsError {
+			numErrors++
+		} else {
+			numWarnings++
+			if onlyErrors {
+				continue
+			}
+		}
+		fmt.Fprintln(os.Stdout, issue)
+	}
+	fmt.Fprintf(os.Stdout, "%d errors, %d warnings\n", numErrors, numWarnings)
+	if numErrors > 0 {
+		return 1
+	}
+	return 0
+}
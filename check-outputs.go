@@ -0,0 +1,424 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// An OutputClaim is a program and the output stated for it, either
+// in the output block following the program, or in a quiz answer.
+type OutputClaim struct {
+	Snippet GoSnippet
+	Output  string
+	Line    int // the line of the stated output
+	IsQuiz  bool
+}
+
+var goRunRegexp = regexp.MustCompile(`^\$ go run [\w.-]+\.go$`)
+
+// documentedOutput returns the output in an output block. The block may
+// start with a "$ go run foo.go" line. Blocks with other shell commands
+// are not program outputs.
+func documentedOutput(code string) (string, bool) {
+	lines := strings.Split(strings.TrimRight(code, "\n"), "\n")
+	if len(lines) > 0 && goRunRegexp.MatchString(strings.TrimSpace(lines[0])) {
+		lines = lines[1:]
+	}
+	for _, line := range lines {
+		if strings.HasPrefix(line, "$ ") {
+			return "", false
+		}
+	}
+	if len(lines) > 0 && strings.HasPrefix(lines[0], "./") && !compileErrorLineRegexp.MatchString(lines[0]) {
+		return "", false
+	}
+	return strings.Join(lines, "\n"), true
+}
+
+var compileErrorLineRegexp = regexp.MustCompile(`^(?:\S*/)?[\w.-]+\.go:(\d+)(?::\d+)?: (.*)$`)
+
+// documentedCompileErrors returns the errors (as "line: message")
+// in an output block, if the block shows compilation errors.
+func documentedCompileErrors(output string) ([]string, bool) {
+	var errs []string
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "# ") {
+			continue
+		}
+		m := compileErrorLineRegexp.FindStringSubmatch(line)
+		if m == nil {
+			return nil, false
+		}
+		errs = append(errs, m[1]+": "+m[2])
+	}
+	return errs, len(errs) > 0
+}
+
+func isOutputBlock(block CodeBlock) bool {
+	for _, c := range strings.Fields(block.Class) {
+		if c == "output" {
+			return true
+		}
+	}
+	return false
+}
+
+// collectOutputClaims returns the output claims in the English articles
+// in the specified groups (all groups if groups is empty). An output block
+// is paired with the program right before it.
+func (go101 *Go101) collectOutputClaims(groups []string, goVersion string) []OutputClaim {
+	if len(groups) == 0 {
+		for group := range go101.pageGroups {
+			groups = append(groups, group)
+		}
+	}
+	sort.Strings(groups)
+
+	var claims []OutputClaim
+	for _, group := range groups {
+		if group == "quizzes" {
+			for _, quiz := range go101.loadQuizzes(DefaultLanguage) {
+				if output, ok := quiz.ExpectedOutput(goVersion); ok {
+					claims = append(claims, OutputClaim{
+						Snippet: newGoSnippet("quizzes/"+quiz.File, quiz.Code),
+						Output:  output,
+						Line:    quiz.AnswerLine,
+						IsQuiz:  true,
+					})
+				}
+			}
+			continue
+		}
+
+		files, _ := listArticleFiles(group)
+		for _, file := range files {
+			if !strings.HasSuffix(file, ".html") {
+				continue
+			}
+			article, err := retrieveArticleContent(DefaultLanguage, group, file)
+			if err != nil {
+				continue
+			}
+			var program *GoSnippet
+			for _, block := range article.CodeBlocks {
+				switch {
+				case strings.EqualFold(block.Lang, "go"):
+					s := newGoSnippet(group+"/"+file, block)
+					program = nil
+					if s.Kind == SnippetProgram && strings.Contains(s.Code, "func main()") {
+						program = &s
+					}
+				case isOutputBlock(block) && program != nil:
+					if output, ok := documentedOutput(block.Code); ok {
+						claims = append(claims, OutputClaim{Snippet: *program, Output: output, Line: block.CodeLine})
+					}
+					program = nil
+				default:
+					program = nil
+				}
+			}
+		}
+	}
+	return claims
+}
+
+var (
+	hexAddressRegexp = regexp.MustCompile(`0x[0-9a-f]{6,}`)
+	exitStatusRegexp = regexp.MustCompile(`^exit status \d+$`)
+	logPrefixRegexp  = regexp.MustCompile(`(?m)^\d{4}/\d\d/\d\d \d\d:\d\d:\d\d `)
+)
+
+// outputLines normalizes an output for comparisons. Addresses, log
+// timestamps, exit statuses and the goroutine traces of panics are removed.
+func outputLines(output string) []string {
+	output = hexAddressRegexp.ReplaceAllString(output, "0x?")
+	output = logPrefixRegexp.ReplaceAllString(output, "")
+	var lines []string
+	panicked := false
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimRight(line, " \t\r")
+		if strings.HasPrefix(line, "panic: ") || strings.HasPrefix(line, "fatal error: ") {
+			panicked = true
+		} else if panicked && strings.HasPrefix(line, "goroutine ") {
+			break
+		}
+		if !exitStatusRegexp.MatchString(line) {
+			lines = append(lines, line)
+		}
+	}
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// matchOutput compares a documented output with an actual one. Lines
+// of "..." in documented outputs match any lines. If they don't match,
+// the index of the first different documented line is returned.
+func matchOutput(documented, actual []string) (ok bool, diffLine int) {
+	// A trailing "..." also matches the removed goroutine traces.
+	if n := len(documented); n > 0 && strings.TrimSpace(documented[n-1]) == "..." {
+		documented = documented[:n-1]
+		for len(documented) > 0 && documented[len(documented)-1] == "" {
+			documented = documented[:len(documented)-1]
+		}
+		documented = append(documented[:len(documented):len(documented)], "...")
+	}
+	var match func(d, a int) bool
+	match = func(d, a int) bool {
+		for ; d < len(documented); d, a = d+1, a+1 {
+			if strings.TrimSpace(documented[d]) == "..." {
+				for k := a; k <= len(actual); k++ {
+					if match(d+1, k) {
+						return true
+					}
+				}
+				return false
+			}
+			if a >= len(actual) || documented[d] != actual[a] {
+				if d > diffLine {
+					diffLine = d
+				}
+				return false
+			}
+		}
+		return a == len(actual)
+	}
+	if match(0, 0) {
+		return true, 0
+	}
+	if diffLine >= len(documented) {
+		diffLine = len(documented) - 1
+	}
+	return false, diffLine
+}
+
+// matchAnswer compares a quiz answer with an actual output,
+// ignoring the differences of white spaces.
+func matchAnswer(answer, actual string) bool {
+	a := strings.Fields(answer)
+	b := strings.Fields(strings.Join(outputLines(actual), "\n"))
+	return strings.Join(a, " ") == strings.Join(b, " ") || strings.Join(a, "") == strings.Join(b, "")
+}
+
+type outputCheckOptions struct {
+	goCmd   string
+	timeout time.Duration // for running each program
+	verbose bool          // also report skipped programs
+	keep    bool          // keep the scratch module
+}
+
+// checkOutputs builds and runs the programs of the claims, and compares
+// their outputs with the claims. A mismatch is a warning instead of an
+// error if the outputs of two runs of the program are different.
+func checkOutputs(claims []OutputClaim, opts outputCheckOptions) ([]SnippetIssue, error) {
+	version, err := localGoVersion(opts.goCmd)
+	if err != nil {
+		return nil, err
+	}
+	std, err := stdPackages(opts.goCmd)
+	if err != nil {
+		return nil, err
+	}
+	dir, err := newScratchModule(version)
+	if err != nil {
+		return nil, err
+	}
+	if opts.keep {
+		fmt.Fprintln(os.Stderr, "scratch module:", dir)
+	} else {
+		defer os.RemoveAll(dir)
+	}
+
+	var issues []SnippetIssue
+	report := func(claim *OutputClaim, line int, isError bool, format string, args ...any) {
+		issues = append(issues, SnippetIssue{claim.Snippet.Page, line, isError, fmt.Sprintf(format, args...)})
+	}
+
+	skipped := map[int]bool{}
+	for i := range claims {
+		s := &claims[i].Snippet
+		if s.ExpectError {
+			skipped[i] = true
+			continue
+		}
+		for _, p := range s.Imports() {
+			if !std[p] {
+				skipped[i] = true
+				if opts.verbose {
+					report(&claims[i], s.Line, false, "skipped: imports non-standard package %s", p)
+				}
+				break
+			}
+		}
+		if skipped[i] {
+			continue
+		}
+		src, _ := s.Source()
+		if err := os.MkdirAll(filepath.Join(dir, snippetDir(i)), 0700); err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(filepath.Join(dir, snippetDir(i), "main.go"), []byte(src), 0644); err != nil {
+			return nil, err
+		}
+	}
+
+	// Programs which fail to compile are not run.
+	bin := filepath.Join(dir, "bin")
+	output, _ := runShellCommand(10*time.Minute, dir, opts.goCmd, "build", "-o", bin+string(filepath.Separator), "./...")
+	compileErrors := map[int][]string{}
+	for _, m := range snippetErrorRegexp.FindAllSubmatch(output, -1) {
+		if i, _ := strconv.Atoi(string(m[2])); i < len(claims) {
+			compileErrors[i] = append(compileErrors[i], string(m[3])+": "+string(m[4]))
+		}
+	}
+
+	run := func(i int) (string, bool) {
+		exe := filepath.Join(bin, snippetDir(i))
+		if runtime.GOOS == "windows" {
+			exe += ".exe"
+		}
+		start := time.Now()
+		output, _ := runShellCommand(opts.timeout, dir, exe)
+		return string(output), time.Since(start) < opts.timeout
+	}
+	for i := range claims {
+		if skipped[i] {
+			continue
+		}
+		claim := &claims[i]
+		errs := compileErrors[i]
+		if documented, ok := documentedCompileErrors(claim.Output); ok && !claim.IsQuiz {
+			switch {
+			case len(errs) == 0:
+				report(claim, claim.Line, true, "documented compilation errors, but it compiles")
+			case strings.Join(documented, "\n") != strings.Join(errs, "\n"):
+				report(claim, claim.Line, false, "documented compilation errors differ from the actual ones:\n%s",
+					outputDiff(documented, errs))
+			}
+			continue
+		}
+		if len(errs) > 0 {
+			report(claim, claim.Snippet.Line, true, "fails to compile: %s", errs[0])
+			continue
+		}
+
+		actual, finished := run(i)
+		if !finished {
+			report(claim, claim.Snippet.Line, false, "timed out after %s", opts.timeout)
+			continue
+		}
+
+		var ok bool
+		var problem string
+		var line = claim.Line
+		if claim.IsQuiz {
+			ok = matchAnswer(claim.Output, actual)
+			problem = fmt.Sprintf("the answer is %q, but the program prints %q",
+				claim.Output, strings.Join(outputLines(actual), "\n"))
+		} else {
+			documented := outputLines(claim.Output)
+			var n int
+			ok, n = matchOutput(documented, outputLines(actual))
+			if !ok {
+				line += n
+				problem = fmt.Sprintf("documented output differs from the actual output:\n%s", outputDiff(documented, outputLines(actual)))
+			}
+		}
+		if ok {
+			continue
+		}
+		if again, _ := run(i); again != actual {
+			report(claim, line, false, "(nondeterministic) %s", problem)
+		} else {
+			report(claim, line, true, "%s", problem)
+		}
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool {
+		if issues[i].Page != issues[j].Page {
+			return issues[i].Page < issues[j].Page
+		}
+		return issues[i].Line < issues[j].Line
+	})
+	return issues, nil
+}
+
+// outputDiff shows two outputs side by side with the different lines marked.
+func outputDiff(documented, actual []string) string {
+	var b strings.Builder
+	n := len(documented)
+	if len(actual) > n {
+		n = len(actual)
+	}
+	for i := 0; i < n; i++ {
+		var d, a string
+		if i < len(documented) {
+			d = documented[i]
+		}
+		if i < len(actual) {
+			a = actual[i]
+		}
+		mark := " "
+		if d != a {
+			mark = "!"
+		}
+		fmt.Fprintf(&b, "\t%s %-40q %q\n", mark, d, a)
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+func runCheckOutputs(args []string) int {
+	flags := flag.NewFlagSet("check-outputs", flag.ExitOnError)
+	groupsFlag := flags.String("groups", "", "comma-separated groups to check (default all)")
+	goFlag := flags.String("go", "go", "the go command to use")
+	timeoutFlag := flags.Duration("timeout", 10*time.Second, "the time limit of running each program")
+	verboseFlag := flags.Bool("v", false, "also report skipped programs")
+	keepFlag := flags.Bool("keep", false, "keep the scratch module")
+	flags.Parse(args)
+
+	version, err := localGoVersion(*goFlag)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	claims := go101.collectOutputClaims(splitGroups(*groupsFlag), version)
+	issues, err := checkOutputs(claims, outputCheckOptions{
+		goCmd:   *goFlag,
+		timeout: *timeoutFlag,
+		verbose: *verboseFlag,
+		keep:    *keepFlag,
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	var numErrors, numQuizzes int
+	for _, issue := range issues {
+		if issue.IsError {
+			numErrors++
+		}
+		fmt.Fprintln(os.Stdout, issue)
+	}
+	for _, claim := range claims {
+		if claim.IsQuiz {
+			numQuizzes++
+		}
+	}
+	fmt.Fprintf(os.Stdout, "%d programs with stated outputs (%d quizzes), go%s: %d errors, %d warnings\n",
+		len(claims), numQuizzes, version, numErrors, len(issues)-numErrors)
+	if numErrors > 0 {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// The behaviors of quiz programs, besides printing something.
+const (
+	QuizFailsToCompile = "fails to compile"
+	QuizPanics         = "panics"
+)
+
+// quizBehavior returns the behavior described by a choice: one of the
+// above ones, or the output if the choice states what is printed.
+func quizBehavior(choice string) string {
+	lower := strings.ToLower(choice)
+	switch {
+	case strings.Contains(lower, "fail to compile"), strings.Contains(lower, "fails to compile"):
+		return QuizFailsToCompile
+	case strings.Contains(lower, "panic") && !printsRegexp.MatchString(choice):
+		return QuizPanics
+	}
+	return stripPrints(choice)
+}
+
+// observedChoice maps the result of running a quiz program onto the
+// choices. It returns -1 if no choices describe the result.
+func (quiz *Quiz) observedChoice(result *PlayResult) int {
+	panicked := false
+	for _, line := range outputLines(result.Output) {
+		if strings.HasPrefix(line, "panic: ") {
+			panicked = true
+		}
+	}
+	for i, choice := range quiz.Choices {
+		switch behavior := quizBehavior(choice); {
+		case result.Errors != "":
+			if behavior == QuizFailsToCompile {
+				return i
+			}
+		case panicked && behavior == QuizPanics:
+			return i
+		case !panicked && behavior != QuizFailsToCompile && behavior != QuizPanics:
+			if matchAnswer(behavior, result.Output) {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// checkQuizzes runs the quiz programs with a toolchain and reports the
+// quizzes whose marked answers are not the observed behaviors.
+func (go101 *Go101) checkQuizzes(tc Toolchain, timeout time.Duration) []SnippetIssue {
+	var issues []SnippetIssue
+	for _, quiz := range go101.loadQuizzes(DefaultLanguage) {
+		page := "quizzes/" + quiz.File
+		report := func(line int, isError bool, format string, args ...any) {
+			issues = append(issues, SnippetIssue{page, line, isError, fmt.Sprintf(format, args...)})
+		}
+
+		marked := quiz.CorrectChoice(tc.Version)
+		if marked < 0 {
+			report(quiz.AnswerLine, true, "the answer %q matches none of the choices", quiz.Answer)
+			continue
+		}
+		s := newGoSnippet(page, quiz.Code)
+		result, err := buildAndRunSnippet(tc, &s, timeout)
+		if err != nil {
+			report(s.Line, true, "%s", err)
+			continue
+		}
+		if result.TimedOut {
+			report(s.Line, false, "timed out")
+			continue
+		}
+
+		observed := quiz.observedChoice(result)
+		observedText := result.Errors
+		if observedText == "" {
+			observedText = strings.Join(outputLines(result.Output), "\n")
+		}
+		switch {
+		case observed < 0:
+			report(quiz.AnswerLine, true, "no choices describe the behavior of the program:\n\t%s",
+				strings.ReplaceAll(strings.TrimSpace(observedText), "\n", "\n\t"))
+		case observed != marked:
+			report(quiz.AnswerLine, true, "the answer is %c (%s), but the behavior with %s is %c (%s)",
+				'A'+marked, quiz.Choices[marked], tc.Version, 'A'+observed, quiz.Choices[observed])
+		}
+	}
+	return issues
+}
+
+// reportSnippetIssues prints the issues and returns the number of errors.
+func reportSnippetIssues(issues []SnippetIssue) (numErrors int) {
+	for _, issue := range issues {
+		if issue.IsError {
+			numErrors++
+		}
+		fmt.Fprintln(os.Stdout, issue)
+	}
+	return numErrors
+}
+
+func runCheckQuizzes(args []string) int {
+	flags := flag.NewFlagSet("check-quizzes", flag.ExitOnError)
+	gorootFlag := flags.String("goroot", "", "the GOROOT of the toolchain to use (default: the one of the go command in PATH)")
+	timeoutFlag := flags.Duration("timeout", time.Minute, "the time limit of building each quiz program")
+	flags.Parse(args)
+
+	tc, err := selectToolchain(*gorootFlag)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	issues := go101.checkQuizzes(tc, *timeoutFlag)
+	numErrors := reportSnippetIssues(issues)
+	fmt.Fprintf(os.Stdout, "%s: %d errors, %d warnings\n", tc.Version, numErrors, len(issues)-numErrors)
+	if numErrors > 0 {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"go/format"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type SnippetIssue struct {
+	Page    string
+	Line    int
+	IsError bool // otherwise, a warning
+	Problem string
+}
+
+func (issue SnippetIssue) String() string {
+	kind := "warning"
+	if issue.IsError {
+		kind = "error"
+	}
+	return fmt.Sprintf("pages/%s:%d: %s: %s", issue.Page, issue.Line, kind, issue.Problem)
+}
+
+// Matches the error lines reported by "go build" and "go vet"
+// in a scratch module (see snippetDir).
+var snippetErrorRegexp = regexp.MustCompile(`(?m)^(vet: )?(?:\./)?s(\d+)/main\.go:(\d+)(?::\d+)?: (.*)$`)
+
+func snippetDir(i int) string {
+	return fmt.Sprintf("s%04d", i)
+}
+
+type snippetCheckOptions struct {
+	goCmd   string
+	vet     bool // also run "go vet"
+	fmt     bool // also check formatting of programs
+	verbose bool // also report problems of fragments
+	keep    bool // keep the scratch module
+}
+
+// checkSnippets compiles the snippets in a scratch module.
+// Only complete programs are required to compile. Problems of
+// fragments are reported as warnings in verbose mode.
+func checkSnippets(snippets []GoSnippet, opts snippetCheckOptions) ([]SnippetIssue, error) {
+	version, err := localGoVersion(opts.goCmd)
+	if err != nil {
+		return nil, err
+	}
+	std, err := stdPackages(opts.goCmd)
+	if err != nil {
+		return nil, err
+	}
+	dir, err := newScratchModule(version)
+	if err != nil {
+		return nil, err
+	}
+	if opts.keep {
+		fmt.Fprintln(os.Stderr, "scratch module:", dir)
+	} else {
+		defer os.RemoveAll(dir)
+	}
+
+	var issues []SnippetIssue
+	report := func(s *GoSnippet, line int, isError bool, format string, args ...any) {
+		issues = append(issues, SnippetIssue{s.Page, line, isError, fmt.Sprintf(format, args...)})
+	}
+
+	// Snippets importing non-standard packages are not checked.
+	headers := make([]int, len(snippets))
+	skipped := map[int]bool{}
+	for i := range snippets {
+		if snippets[i].Kind == SnippetMultiFiles {
+			skipped[i] = true
+			continue
+		}
+		for _, p := range snippets[i].Imports() {
+			if !std[p] {
+				skipped[i] = true
+				if opts.verbose {
+					report(&snippets[i], snippets[i].Line, false, "skipped: imports non-standard package %s", p)
+				}
+				break
+			}
+		}
+		if skipped[i] {
+			continue
+		}
+		src, headerLines := snippets[i].Source()
+		headers[i] = headerLines
+		if err := os.MkdirAll(filepath.Join(dir, snippetDir(i)), 0700); err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(filepath.Join(dir, snippetDir(i), "main.go"), []byte(src), 0644); err != nil {
+			return nil, err
+		}
+	}
+
+	type compileError struct {
+		line    int
+		message string
+	}
+	parseErrors := func(output []byte, onlyVet bool) map[int][]compileError {
+		errs := map[int][]compileError{}
+		for _, m := range snippetErrorRegexp.FindAllSubmatch(output, -1) {
+			if onlyVet && len(m[1]) > 0 {
+				continue // type errors reported by vet
+			}
+			i, _ := strconv.Atoi(string(m[2]))
+			line, _ := strconv.Atoi(string(m[3]))
+			if i < len(snippets) {
+				errs[i] = append(errs[i], compileError{line, string(m[4])})
+			}
+		}
+		return errs
+	}
+
+	output, _ := runShellCommand(10*time.Minute, dir, opts.goCmd, "build", "./...")
+	buildErrors := parseErrors(output, false)
+	var vetErrors map[int][]compileError
+	if opts.vet {
+		output, _ := runShellCommand(10*time.Minute, dir, opts.goCmd, "vet", "./...")
+		vetErrors = parseErrors(output, true)
+	}
+
+	for i := range snippets {
+		if skipped[i] {
+			continue
+		}
+		s := &snippets[i]
+		// Unparsable programs are errors, unless they omit code with "...".
+		isProgram := s.Kind == SnippetProgram ||
+			packageClauseRegexp.MatchString(s.Code) && !isPseudoCode(s.Code)
+		errs := buildErrors[i]
+		switch {
+		case s.ExpectError && len(errs) == 0:
+			report(s, s.Line, true, "expected to fail to compile, but it compiles")
+		case s.ExpectError:
+		case len(errs) > 0 && (isProgram || opts.verbose):
+			for _, e := range errs {
+				report(s, s.PageLine(e.line, headers[i]), isProgram, "%s (%s)", e.message, s.Kind)
+			}
+		}
+		if s.ExpectError || len(errs) > 0 {
+			continue
+		}
+
+		for _, e := range vetErrors[i] {
+			report(s, s.PageLine(e.line, headers[i]), false, "vet: %s", e.message)
+		}
+		if opts.fmt && s.Kind == SnippetProgram {
+			if formatted, err := format.Source([]byte(s.Code)); err == nil && string(formatted) != s.Code {
+				report(s, s.Line, false, "not gofmt-ed")
+			}
+		}
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool {
+		if issues[i].Page != issues[j].Page {
+			return issues[i].Page < issues[j].Page
+		}
+		return issues[i].Line < issues[j].Line
+	})
+	return issues, nil
+}
+
+// splitGroups splits a comma-separated group list.
+func splitGroups(list string) []string {
+	var groups []string
+	for _, g := range strings.Split(list, ",") {
+		if g = strings.TrimSpace(g); g != "" {
+			groups = append(groups, g)
+		}
+	}
+	return groups
+}
+
+func runCheckSnippets(args []string) int {
+	flags := flag.NewFlagSet("check-snippets", flag.ExitOnError)
+	groupsFlag := flags.String("groups", "", "comma-separated groups to check (default all)")
+	goFlag := flags.String("go", "go", "the go command to use")
+	vetFlag := flags.Bool("vet", false, "also run go vet and report its findings")
+	fmtFlag := flags.Bool("fmt", false, "also report programs which are not gofmt-ed")
+	verboseFlag := flags.Bool("v", false, "also report the problems of code fragments")
+	keepFlag := flags.Bool("keep", false, "keep the scratch module")
+	flags.Parse(args)
+
+	snippets := go101.collectGoSnippets(splitGroups(*groupsFlag))
+	issues, err := checkSnippets(snippets, snippetCheckOptions{
+		goCmd:   *goFlag,
+		vet:     *vetFlag,
+		fmt:     *fmtFlag,
+		verbose: *verboseFlag,
+		keep:    *keepFlag,
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	var numErrors int
+	for _, issue := range issues {
+		if issue.IsError {
+			numErrors++
+		}
+		fmt.Fprintln(os.Stdout, issue)
+	}
+	counts := map[SnippetKind]int{}
+	for _, s := range snippets {
+		counts[s.Kind]++
+	}
+	fmt.Fprintf(os.Stdout, "%d snippets (%d programs, %d declarations, %d statements, %d unparsable, %d multi-files): %d errors, %d warnings\n",
+		len(snippets), counts[SnippetProgram], counts[SnippetDeclarations], counts[SnippetStatements], counts[SnippetInvalid],
+		counts[SnippetMultiFiles], numErrors, len(issues)-numErrors)
+	if numErrors > 0 {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Commands are run as "go101 [flags] command [args]".
+// A command doesn't start the web server.
+var commands = map[string]struct {
+	run   func(args []string) (exitCode int)
+	brief string
+}{
+	"i18n-status":      {runI18nStatus, "report missing and outdated translations"},
+	"check-links":      {runCheckLinks, "check internal links, anchors and images in all pages"},
+	"check-index":      {runCheckIndex, "report orphan articles and inconsistencies of book indexes"},
+	"check-snippets":   {runCheckSnippets, "compile-check the Go code snippets in all pages"},
+	"check-outputs":    {runCheckOutputs, "run programs and compare their outputs with the documented ones"},
+	"check-quizzes":    {runCheckQuizzes, "run the quiz programs and check the marked answers against their behaviors"},
+	"quiz":             {runQuiz, "take the quizzes in the terminal"},
+	"quiz-stats":       {runQuizStats, "list the quizzes by the ratios of readers picking the right choices"},
+	"bench":            {runBench, "run the benchmarks of the optimizations book and record the results"},
+	"bugs-status":      {runBugsStatus, "run the reproducers of the bugs in the bugs group with the local toolchain"},
+	"toolchain-matrix": {runToolchainMatrix, "run a snippet with all installed toolchains and show the output differences"},
+}
+
+func runCommand(name string, args []string) {
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command: %s\n\n%s", name, commandsUsage())
+		os.Exit(2)
+	}
+	os.Exit(cmd.run(args))
+}
+
+func commandsUsage() string {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	b.WriteString("Commands:\n")
+	for _, name := range names {
+		fmt.Fprintf(&b, "  %-16s %s\n", name, commands[name].brief)
+	}
+	return b.String()
+}
@@ -192,14 +192,52 @@ func genStaticFiles(rootURL string) {
 		}
 	}
 
+	// Every page is also generated for each translation language.
+	// Untranslated pages are generated with the English contents.
+	collectTranslationFiles := func(lang, group, urlPrefix string) {
+		names := map[string]bool{}
+		for _, dir := range []string{group, lang + "/" + group} {
+			if _, err := os.Stat(fullPath("pages", dir)); err != nil {
+				continue
+			}
+			if dir != group {
+				md2htmls(dir)
+				tmd2htmls(dir)
+			}
+
+			filenames, _ := readFolder(fullPath("pages", dir))
+			for _, f := range filenames {
+				if strings.HasSuffix(f, ".html") {
+					names[filepath.Base(f)] = true
+				}
+			}
+			filenames, _ = readFolder(fullPath("pages", dir, "res"))
+			for _, f := range filenames {
+				if strings.HasSuffix(f, ".png") || strings.HasSuffix(f, ".jpg") {
+					names["res/"+filepath.Base(f)] = true
+				}
+			}
+		}
+
+		for name := range names {
+			uri := lang + "/" + urlPrefix + name
+			files[uri] = loadFile(uri)
+		}
+	}
+
 	{
 		infos, err := os.ReadDir(fullPath("pages"))
 		if err != nil {
 			panic("collect page groups error: " + err.Error())
 		}
 
+		var translationLangs []string
+		groupURLPrefixes := map[string]string{}
+
 		for _, e := range infos {
-			if e.IsDir() {
+			if e.IsDir() && isTranslationFolder(e.Name()) {
+				translationLangs = append(translationLangs, e.Name())
+			} else if e.IsDir() {
 				group := e.Name()
 
 				var urlPrefix string
@@ -216,6 +254,23 @@ func genStaticFiles(rootURL string) {
 				}
 
 				collectPageGroupFiles(group, urlPrefix, collectRes)
+				groupURLPrefixes[group] = urlPrefix
+			}
+		}
+
+		for _, lang := range translationLangs {
+			for group, urlPrefix := range groupURLPrefixes {
+				collectTranslationFiles(lang, group, urlPrefix)
+			}
+		}
+
+		// Topic pages are generated from the articles collected above.
+		for _, lang := range append([]string{DefaultLanguage}, translationLangs...) {
+			prefix := strings.TrimPrefix(langURLPrefix(lang)+"/", "/")
+			files[prefix+"topics/index.html"] = loadFile(prefix + "topics/")
+			for _, topic := range go101.collectTopics(lang) {
+				uri := prefix + "topics/" + topic.Slug + ".html"
+				files[uri] = loadFile(uri)
 			}
 		}
 	}
@@ -74,7 +74,7 @@ var gogetInfos = map[string]GoGetInfo{
 	},
 }
 
-func (go101 *Go101) ServeGoGetPages(w http.ResponseWriter, r *http.Request, rootPkg, subPkg string) {
+func (go101 *Go101) ServeGoGetPages(w http.ResponseWriter, r *http.Request, lang, rootPkg, subPkg string) {
 	var version string
 	if subPkg != "" {
 		atIndex := strings.IndexByte(subPkg, '@')
@@ -101,19 +101,14 @@ func (go101 *Go101) ServeGoGetPages(w http.ResponseWriter, r *http.Request, root
 			if rootPkg == "" {
 				rootPkg = "index.html"
 			}
-			go101.serveGroupItem(w, r, "website", rootPkg)
+			go101.serveGroupItem(w, r, lang, "website", rootPkg)
 		} else {
 			http.Redirect(w, r, "/", http.StatusNotFound)
 		}
 		return
 	}
 
-	item := rootPkg
-	if subPkg != "" {
-		item += "/" + subPkg
-	}
-
-	page, isLocal := go101.gogetPages.Get(item, version), go101.IsLocalServer()
+	page, isLocal := go101.gogetPages.Get(rootPkg, subPkg+version), go101.IsLocalServer()
 	if page == nil {
 		info.GoGetSourceRepo = "https://github.com/" + info.GoGetSourceRepo
 		if info.GoDocWebsite != "" {
@@ -135,7 +130,7 @@ func (go101 *Go101) ServeGoGetPages(w http.ResponseWriter, r *http.Request, root
 		}
 
 		if !isLocal {
-			go101.gogetPages.Set(item, version, page)
+			go101.gogetPages.Set(rootPkg, subPkg+version, page)
 		}
 	}
 
@@ -0,0 +1,331 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type SnippetKind int
+
+const (
+	SnippetProgram      SnippetKind = iota // a complete Go source file
+	SnippetDeclarations                    // top-level declarations
+	SnippetStatements                      // statements in a function body
+	SnippetInvalid                         // can't be parsed in any way
+	SnippetMultiFiles                      // several source files, not checked
+)
+
+func (k SnippetKind) String() string {
+	return [...]string{"program", "declarations", "statements", "invalid", "multi-files"}[k]
+}
+
+// A GoSnippet is the code in a <code class="language-go"> block.
+type GoSnippet struct {
+	Page        string // relative to the pages folder
+	Line        int    // the line of the first code line in the page
+	Code        string
+	Kind        SnippetKind
+	ExpectError bool // the code is intended to fail to compile
+}
+
+func (s *GoSnippet) String() string {
+	return fmt.Sprintf("pages/%s:%d", s.Page, s.Line)
+}
+
+// A snippet is expected to fail to compile if it contains "// error: ..."
+// comments (or "// compile error", "// fails to compile"), either after
+// code or in their own lines. Commented-out code is ignored.
+var (
+	blockCommentRegexp = regexp.MustCompile(`(?s)/\*.*?\*/`)
+	errorMarkRegexp    = regexp.MustCompile(`^\s*((compile |compilation )?error\b|fails? to compile\b)`)
+	codeErrorRegexp    = regexp.MustCompile(`\bfails? to compile\b`)
+)
+
+func expectsCompileError(code string) bool {
+	lines := strings.Split(blockCommentRegexp.ReplaceAllString(code, ""), "\n")
+	for n, line := range lines {
+		i := strings.Index(line, "//")
+		if i < 0 {
+			continue
+		}
+		comment := line[i+2:]
+		if strings.TrimSpace(line[:i]) != "" {
+			if errorMarkRegexp.MatchString(comment) || codeErrorRegexp.MatchString(comment) {
+				return true
+			}
+			continue
+		}
+		if errorMarkRegexp.MatchString(comment) && !isCommentedOutCode(lines[n+1:]) {
+			return true
+		}
+	}
+	return false
+}
+
+// isCommentedOutCode reports whether or not the lines following an
+// error comment line are commented out. Comment lines indented after
+// "//" are viewed as the continuation lines of the error comment.
+func isCommentedOutCode(following []string) bool {
+	for _, line := range following {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "":
+		case !strings.HasPrefix(trimmed, "//"):
+			return false
+		case !strings.HasPrefix(trimmed, "//  "):
+			return true
+		}
+	}
+	return true
+}
+
+// isPseudoCode reports whether or not some code contains "..." lines,
+// which are used to omit code.
+func isPseudoCode(code string) bool {
+	for _, line := range strings.Split(code, "\n") {
+		if line = strings.TrimSpace(line); strings.HasPrefix(line, "...") && !strings.HasPrefix(line, "...)") {
+			return true
+		}
+	}
+	return false
+}
+
+// Some snippets show several source files.
+var packageClauseRegexp = regexp.MustCompile(`(?m)^package \w+`)
+
+// collectGoSnippets returns the Go code snippets of the English articles
+// in the specified groups (all groups if groups is empty).
+func (go101 *Go101) collectGoSnippets(groups []string) []GoSnippet {
+	if len(groups) == 0 {
+		for group := range go101.pageGroups {
+			groups = append(groups, group)
+		}
+	}
+	sort.Strings(groups)
+
+	var snippets []GoSnippet
+	for _, group := range groups {
+		files, _ := listArticleFiles(group)
+		for _, file := range files {
+			if !strings.HasSuffix(file, ".html") {
+				continue
+			}
+			article, err := retrieveArticleContent(DefaultLanguage, group, file)
+			if err != nil {
+				continue
+			}
+			for _, block := range article.CodeBlocks {
+				if strings.EqualFold(block.Lang, "go") && strings.TrimSpace(block.Code) != "" {
+					snippets = append(snippets, newGoSnippet(group+"/"+file, block))
+				}
+			}
+		}
+	}
+	return snippets
+}
+
+func newGoSnippet(page string, block CodeBlock) GoSnippet {
+	s := GoSnippet{Page: page, Line: block.CodeLine, Code: block.Code}
+	s.ExpectError = expectsCompileError(s.Code)
+
+	fset := token.NewFileSet()
+	switch {
+	case len(packageClauseRegexp.FindAllStringIndex(s.Code, 2)) > 1:
+		s.Kind = SnippetMultiFiles
+	case isParsable(fset, s.Code):
+		s.Kind = SnippetProgram
+	case isParsable(fset, "package main\n"+s.Code):
+		s.Kind = SnippetDeclarations
+	case isParsable(fset, "package main\nfunc main() {\n"+s.Code+"\n}"):
+		s.Kind = SnippetStatements
+	default:
+		s.Kind = SnippetInvalid
+	}
+	return s
+}
+
+func isParsable(fset *token.FileSet, src string) bool {
+	_, err := parser.ParseFile(fset, "", src, parser.SkipObjectResolution)
+	return err == nil
+}
+
+// The standard packages which are imported automatically
+// for snippets without package clauses.
+var snippetImports = map[string]string{
+	"atomic":  "sync/atomic",
+	"bufio":   "bufio",
+	"bytes":   "bytes",
+	"cmp":     "cmp",
+	"context": "context",
+	"debug":   "runtime/debug",
+	"errors":  "errors",
+	"fmt":     "fmt",
+	"io":      "io",
+	"iter":    "iter",
+	"json":    "encoding/json",
+	"log":     "log",
+	"maps":    "maps",
+	"math":    "math",
+	"os":      "os",
+	"rand":    "math/rand",
+	"reflect": "reflect",
+	"runtime": "runtime",
+	"slices":  "slices",
+	"sort":    "sort",
+	"strconv": "strconv",
+	"strings": "strings",
+	"sync":    "sync",
+	"time":    "time",
+	"unicode": "unicode",
+	"unsafe":  "unsafe",
+	"utf8":    "unicode/utf8",
+}
+
+// Source returns the code of a Go source file for the snippet. Snippets
+// without package clauses are put in a main package, with the standard
+// packages they use imported. The header lines of the returned code are
+// placed in the first headerLines lines.
+func (s *GoSnippet) Source() (src string, headerLines int) {
+	switch s.Kind {
+	case SnippetProgram:
+		return s.Code, 0
+	case SnippetDeclarations:
+		src := "package main; " + s.imports() + "\n" + s.Code
+		if !strings.Contains(s.Code, "func main()") {
+			src += "\nfunc main() {}\n"
+		}
+		return src, 1
+	default:
+		return "package main; " + s.imports() + "\nfunc main() {\n" + s.Code + "\n}\n", 2
+	}
+}
+
+func (s *GoSnippet) imports() string {
+	code := s.Code
+	if s.Kind == SnippetStatements {
+		code = "func main() {\n" + code + "\n}"
+	}
+	f, err := parser.ParseFile(token.NewFileSet(), "", "package main\n"+code, parser.SkipObjectResolution)
+	if err != nil || len(f.Imports) > 0 {
+		return ""
+	}
+
+	used := map[string]bool{}
+	ast.Inspect(f, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if id, ok := sel.X.(*ast.Ident); ok && snippetImports[id.Name] != "" {
+				used[snippetImports[id.Name]] = true
+			}
+		}
+		return true
+	})
+	if len(used) == 0 {
+		return ""
+	}
+	paths := make([]string, 0, len(used))
+	for p := range used {
+		paths = append(paths, fmt.Sprintf("%q", p))
+	}
+	sort.Strings(paths)
+	return "import (" + strings.Join(paths, "; ") + ")"
+}
+
+// PageLine converts a line in the source returned by Source
+// to a line in the page containing the snippet.
+func (s *GoSnippet) PageLine(srcLine, headerLines int) int {
+	n := srcLine - headerLines
+	if n < 1 || n > strings.Count(s.Code, "\n")+1 {
+		return s.Line
+	}
+	return s.Line + n - 1
+}
+
+// Imports returns the import paths used in the source of the snippet.
+func (s *GoSnippet) Imports() []string {
+	src, _ := s.Source()
+	f, err := parser.ParseFile(token.NewFileSet(), "", src, parser.ImportsOnly)
+	if err != nil {
+		return nil
+	}
+	paths := make([]string, 0, len(f.Imports))
+	for _, imp := range f.Imports {
+		if p, err := strconv.Unquote(imp.Path.Value); err == nil {
+			paths = append(paths, p)
+		}
+	}
+	return paths
+}
+
+// stdPackages returns the paths of the standard packages.
+func stdPackages(goCmd string) (map[string]bool, error) {
+	output, err := runShellCommand(time.Minute, "", goCmd, "list", "std")
+	if err != nil {
+		return nil, fmt.Errorf("%s list std: %w: %s", goCmd, err, output)
+	}
+	std := map[string]bool{"C": true}
+	for _, p := range strings.Fields(string(output)) {
+		std[p] = true
+	}
+	return std, nil
+}
+
+// localGoVersion returns the language version (such as 1.22)
+// of the go command in PATH.
+func localGoVersion(goCmd string) (string, error) {
+	output, err := runShellCommand(time.Minute/2, "", goCmd, "env", "GOVERSION")
+	if err != nil {
+		return "", fmt.Errorf("%s env GOVERSION: %w: %s", goCmd, err, output)
+	}
+	v := strings.TrimPrefix(string(bytes.TrimSpace(output)), "go")
+	if parts := strings.SplitN(v, ".", 3); len(parts) >= 2 {
+		v = parts[0] + "." + strings.TrimRightFunc(parts[1], func(r rune) bool {
+			return r < '0' || r > '9'
+		})
+	}
+	if v == "" {
+		return "", errors.New("unknown go version: " + string(output))
+	}
+	return v, nil
+}
+
+// goVersionAtLeast reports whether or not the language version v
+// (such as 1.22) is not older than min.
+func goVersionAtLeast(v, min string) bool {
+	parse := func(v string) (major, minor int) {
+		parts := strings.SplitN(strings.TrimPrefix(v, "go"), ".", 3)
+		major, _ = strconv.Atoi(parts[0])
+		if len(parts) > 1 {
+			minor, _ = strconv.Atoi(parts[1])
+		}
+		return
+	}
+	vMajor, vMinor := parse(v)
+	mMajor, mMinor := parse(min)
+	return vMajor > mMajor || vMajor == mMajor && vMinor >= mMinor
+}
+
+// newScratchModule creates a temporary module for running snippets.
+// The caller should remove the returned folder when it is not used.
+func newScratchModule(goVersion string) (string, error) {
+	dir, err := os.MkdirTemp("", "go101-snippets-")
+	if err != nil {
+		return "", err
+	}
+	mod := fmt.Sprintf("module snippets\n\ngo %s\n", goVersion)
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte(mod), 0644); err != nil {
+		os.RemoveAll(dir)
+		return "", err
+	}
+	return dir, nil
+}
@@ -31,22 +31,30 @@ var staticFilesHandler = func() http.Handler {
 	return http.FileServer(http.FS(staticFiles))
 }()
 
-func collectPageGroups() map[string]*PageGroup {
+// collectPageGroups returns nil if there are no pages in the specified language.
+func collectPageGroups(lang string) map[string]*PageGroup {
 	if wdIsGo101ProjectRoot {
-		return collectPageGroups_NonEmbedding()
+		return collectPageGroups_NonEmbedding(lang)
 	}
 
-	entries, err := fs.ReadDir(allFiles, "pages")
+	dir := "pages"
+	if lang != DefaultLanguage {
+		dir = path.Join(dir, lang)
+	}
+	entries, err := fs.ReadDir(allFiles, dir)
 	if err != nil {
+		if lang != DefaultLanguage && errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
 		panic("collect page groups (embedding) error: " + err.Error())
 	}
 
 	pageGroups := make(map[string]*PageGroup, len(entries))
 
 	for _, e := range entries {
-		if e.IsDir() {
+		if e.IsDir() && !(lang == DefaultLanguage && isTranslationFolder(e.Name())) {
 			group, handler := e.Name(), dummyHandler
-			resFiles, err := fs.Sub(allFiles, path.Join("pages", e.Name(), "res"))
+			resFiles, err := fs.Sub(allFiles, path.Join(dir, e.Name(), "res"))
 			if err == nil {
 				var urlGroup string
 				// For history reason, fundamentals pages uses "/article/xxx" URLs.
@@ -79,6 +87,44 @@ func loadArticleFile(group, file string) ([]byte, error) {
 	return content, nil
 }
 
+func articleFileExists(group, file string) bool {
+	if wdIsGo101ProjectRoot {
+		return articleFileExists_NonEmbedding(group, file)
+	}
+
+	info, err := fs.Stat(allFiles, path.Join("pages", group, file))
+	return err == nil && !info.IsDir()
+}
+
+func staticFileExists(file string) bool {
+	if wdIsGo101ProjectRoot {
+		return staticFileExists_NonEmbedding(file)
+	}
+
+	info, err := fs.Stat(allFiles, path.Join("web", "static", file))
+	return err == nil && !info.IsDir()
+}
+
+// listArticleFiles returns the names of the files (not including
+// folders) in a folder (relative to the pages folder).
+func listArticleFiles(dir string) ([]string, error) {
+	if wdIsGo101ProjectRoot {
+		return listArticleFiles_NonEmbedding(dir)
+	}
+
+	entries, err := fs.ReadDir(allFiles, path.Join("pages", dir))
+	if err != nil {
+		return nil, err
+	}
+	files := make([]string, 0, len(entries))
+	for _, e := range entries {
+		if !e.IsDir() {
+			files = append(files, e.Name())
+		}
+	}
+	return files, nil
+}
+
 func parseTemplate(commonPaths []string, files ...string) *template.Template {
 	if wdIsGo101ProjectRoot {
 		return parseTemplate_NonEmbedding(commonPaths, files...)
@@ -5,11 +5,12 @@ import (
 	"context"
 	"errors"
 	"go/build"
-	"html"
 	"html/template"
 	"io/fs"
 	"log"
+	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -23,30 +24,74 @@ type Go101 struct {
 	staticHandler http.Handler
 	isLocalServer bool
 	pageGroups    map[string]*PageGroup
+	translations  map[string]map[string]*PageGroup // lang -> group -> pages
+	linkGraph     *LinkGraph                       // built on first use, see articleLinkGraph
+	linkGraphOnce sync.Once
+	playground    *playground  // nil means disabled
+	reader        *readerStore // nil means disabled
+	quizStats     *quizStats   // nil means disabled
 	articlePages  Cache
 	gogetPages    Cache
 	serverMutex   sync.Mutex
 	theme         string
+
+	quizCache      map[string][]APIQuiz // by languages, see apiQuizzes
+	quizCacheMutex sync.Mutex
 }
 
 type PageGroup struct {
 	resHandler   http.Handler
 	indexContent template.HTML
+	chapters     []Chapter // in the order of the index
 }
 
 var go101 = &Go101{
 	staticHandler: http.StripPrefix("/static/", staticFilesHandler),
 	isLocalServer: false, // may be modified later
-	pageGroups:    collectPageGroups(),
+	pageGroups:    collectPageGroups(DefaultLanguage),
+	translations:  collectTranslations(),
 }
 
 func init() {
 	for group, pg := range go101.pageGroups {
-		pg.indexContent = retrieveIndexContent(group)
+		pg.indexContent, pg.chapters = retrieveIndexContent(DefaultLanguage, group)
+	}
+	for lang, pageGroups := range go101.translations {
+		for group, pg := range pageGroups {
+			pg.indexContent, pg.chapters = retrieveIndexContent(lang, group)
+		}
+	}
+}
+
+func collectTranslations() map[string]map[string]*PageGroup {
+	translations := map[string]map[string]*PageGroup{}
+	for lang := range languages {
+		if lang == DefaultLanguage {
+			continue
+		}
+		if pageGroups := collectPageGroups(lang); pageGroups != nil {
+			translations[lang] = pageGroups
+		}
 	}
+	return translations
 }
 
 func (go101 *Go101) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	go101.RememberLanguage(w, r)
+	if go101.RedirectToCanonicalPath(w, r) {
+		return
+	}
+
+	// Translated pages are served with the same handlers as the English
+	// ones, so the language prefixes are removed from the URLs here.
+	lang, urlPath := go101.splitLanguagePrefix(r.URL.Path)
+	if lang == "" {
+		lang = DefaultLanguage
+	} else {
+		r = r.Clone(r.Context())
+		r.URL.Path, r.URL.RawPath = urlPath, ""
+	}
+
 	var group, item string
 	if tokens := strings.SplitN(r.URL.Path[1:], "/", 2); len(tokens) == 2 {
 		group, item = tokens[0], tokens[1]
@@ -56,30 +101,60 @@ func (go101 *Go101) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	switch go101.PreHandle(w, r); group {
 	default:
-		go101.ServeGoGetPages(w, r, group, item)
+		go101.ServeGoGetPages(w, r, lang, group, item)
 	case "":
-		go101.ServeGoGetPages(w, r, item, "")
+		go101.ServeGoGetPages(w, r, lang, item, "")
 	case "res":
-		go101.serveGroupItem(w, r, "website", r.URL.Path[1:])
+		go101.serveGroupItem(w, r, lang, "website", r.URL.Path[1:])
 	case "static":
 		w.Header().Set("Cache-Control", "max-age=31536000") // one year
 		go101.staticHandler.ServeHTTP(w, r)
+	case "admin":
+		switch item {
+		default:
+			http.NotFound(w, r)
+		case "i18n":
+			go101.ServeI18nStatusPage(w, r)
+		}
+	case "topics":
+		go101.ServeTopicPage(w, r, lang, item)
+	case "play":
+		go101.ServePlay(w, r, item)
+	case "api":
+		go101.ServeAPI(w, r, lang, item)
+	case "me":
+		go101.ServeMePage(w, r, lang, item)
 	case "article":
 		// for history reason, fundamentals pages use "article/xxx" URLs
-		go101.serveGroupItem(w, r, "fundamentals", item)
-	case "optimizations", "details-and-tips", "quizzes", "generics",
+		go101.serveGroupItem(w, r, lang, "fundamentals", item)
+	case "quizzes":
+		if item == "review" {
+			go101.ServeQuizReviewPage(w, r, lang)
+			return
+		}
+		go101.serveGroupItem(w, r, lang, group, item)
+	case "optimizations", "details-and-tips", "generics",
 		"apps-and-libs", "blog", "q-and-a", "bugs", "practices":
-		go101.serveGroupItem(w, r, group, item)
+		go101.serveGroupItem(w, r, lang, group, item)
 	}
 }
 
-func (go101 *Go101) serveGroupItem(w http.ResponseWriter, r *http.Request, group, item string) {
-	item = strings.ToLower(item)
+func (go101 *Go101) serveGroupItem(w http.ResponseWriter, r *http.Request, lang, group, item string) {
 	if strings.HasPrefix(item, "res/") {
 		w.Header().Set("Cache-Control", "max-age=31536000") // one year
-		go101.pageGroups[group].resHandler.ServeHTTP(w, r)
-	} else if !go101.RedirectArticlePage(w, r, group, item) {
-		go101.RenderArticlePage(w, r, group, item)
+		pg := go101.pageGroups[group]
+		if tpg := go101.translations[lang][group]; tpg != nil && articleFileExists(langGroupDir(lang, group), item) {
+			pg = tpg
+		}
+		pg.resHandler.ServeHTTP(w, r)
+		return
+	}
+
+	if lang == DefaultLanguage && go101.RedirectToPreferredLanguage(w, r, group, item) {
+		return
+	}
+	if !go101.RedirectArticlePage(w, r, lang, group, item) {
+		go101.RenderArticlePage(w, r, lang, group, item)
 	}
 }
 
@@ -94,6 +169,7 @@ func (go101 *Go101) PreHandle(w http.ResponseWriter, r *http.Request) {
 			unloadPageTemplates()      // loaded in one init function
 			go101.articlePages.Clear() // invalidate article caches
 			go101.gogetPages.Clear()   // invalidate go-gets caches
+			go101.clearQuizCache()
 		}
 	}
 }
@@ -105,6 +181,14 @@ func (go101 *Go101) IsLocalServer() (isLocal bool) {
 	return
 }
 
+// setFeatureParams sets the page params telling which optional features
+// are enabled, so that the scripts of the disabled ones are not loaded.
+func (go101 *Go101) setFeatureParams(pageParams map[string]any) {
+	pageParams["Play"] = go101.playground != nil
+	pageParams["Reader"] = go101.reader != nil
+	pageParams["QuizStats"] = go101.quizStats != nil
+}
+
 func pullGo101Project(wd string) {
 	<-time.After(time.Minute / 2)
 	gitPull(wd)
@@ -123,23 +207,83 @@ type Article struct {
 	TitleWithoutTags      string
 	Group, Filename       string
 	FilenameWithoutExt    string
+
+	// Extracted from the content.
+	Headings   []*Heading // a tree, the title is included
+	TOC        []*Heading // the headings without the title
+	Links      []ArticleLink
+	Images     []ArticleImage
+	CodeBlocks []CodeBlock
+	WordCount  int
+
+	Meta   ArticleMeta
+	Topics []Topic     // only the names and slugs are set
+	Bugs   []BugStatus // only for pages in the bugs group
+
+	Benchmarks *ArticleBenchmarks // only for pages in the optimizations group
+
+	// From the link graph of all articles.
+	ReferencedBy []RelatedArticle
+	Related      []RelatedArticle
+
+	// Set if the article is listed in the index of its group.
+	PrevChapter, NextChapter   *Chapter
+	ChapterNumber, NumChapters int
+
+	Language     string
+	LangPrefix   string // URL path prefix, blank for English
+	Untranslated bool   // English content shown as a fallback
+	Alternates   []LanguageAlternate
 }
 
 var schemes = map[bool]string{false: "http://", true: "https://"}
 
-func (go101 *Go101) RenderArticlePage(w http.ResponseWriter, r *http.Request, group, file string) {
-	page, isLocal := go101.articlePages.Get(group, file), go101.IsLocalServer()
+func (go101 *Go101) RenderArticlePage(w http.ResponseWriter, r *http.Request, lang, group, file string) {
+	page, isLocal := go101.articlePages.Get(langGroupDir(lang, group), file), go101.IsLocalServer()
 	if page == nil {
-		article, err := retrieveArticleContent(group, file)
+		canonicalLang := lang
+		article, err := retrieveArticleContent(lang, group, file)
+		if lang != DefaultLanguage && errors.Is(err, fs.ErrNotExist) {
+			canonicalLang = DefaultLanguage
+			article, err = retrieveArticleContent(DefaultLanguage, group, file)
+			article.Language, article.Untranslated = lang, true
+		}
 		if err == nil {
-			article.Index = disableArticleLink(go101.pageGroups[group].indexContent, file)
+			article.LangPrefix = langURLPrefix(lang)
+			article.Alternates = go101.languageAlternates(group, file)
+			indexPG := go101.indexPageGroup(lang, group)
+			article.Index = disableArticleLink(indexPG.indexContent, file)
+			article.setChapterNavigation(indexPG.chapters)
+			article.Meta = loadArticleMeta(canonicalLang, group, file)
+			article.Meta.Related = go101.resolveRelatedArticles(lang, group, article.Meta.Related)
+			go101.articleLinkGraph().setArticleLinks(&article, lang)
+			if group == "bugs" && len(article.Meta.Bugs) > 0 {
+				article.Bugs = loadBugStatuses(file)
+			}
+			if group == "optimizations" {
+				article.Benchmarks = loadArticleBenchmarks(file)
+			}
+			if isListedInTopics(file) {
+				for _, name := range articleTopics(&article) {
+					if slug := topicSlug(name); slug != "" {
+						article.Topics = append(article.Topics, Topic{Name: name, Slug: slug})
+					}
+				}
+			}
 			pageParams := map[string]any{
-				"Article": article,
-				"Title":   article.TitleWithoutTags,
-				"Theme":   go101.theme,
+				"Article":            article,
+				"Title":              article.TitleWithoutTags,
+				"CanonicalURL":       canonicalArticleURL(canonicalLang, group, file),
+				"UntranslatedNotice": languages[lang].UntranslatedNotice,
+				"CSPNonce":           cspNoncePlaceholder,
+				"Theme":              go101.theme,
 				//"IsLocalServer": isLocal,
 				"GoVersion": runtime.Version(),
 			}
+			go101.setFeatureParams(pageParams)
+			if group == "website" && file == "index.html" {
+				pageParams["Topics"] = go101.collectTopics(lang)
+			}
 			t := retrievePageTemplate(Template_Article, !isLocal)
 			var buf bytes.Buffer
 			if err = t.Execute(&buf, pageParams); err == nil {
@@ -152,12 +296,12 @@ func (go101 *Go101) RenderArticlePage(w http.ResponseWriter, r *http.Request, gr
 		}
 
 		if !isLocal {
-			go101.articlePages.Set(group, file, page)
+			go101.articlePages.Set(langGroupDir(lang, group), file, page)
 		}
 	}
 
 	if len(page) == 0 { // blank page means page not found.
-		log.Printf("article page %s/%s is not found", group, file)
+		log.Printf("article page %s/%s is not found", langGroupDir(lang, group), file)
 		//w.Header().Set("Cache-Control", "no-cache, private, max-age=0")
 		http.Redirect(w, r, "/", http.StatusNotFound)
 		return
@@ -168,134 +312,35 @@ func (go101 *Go101) RenderArticlePage(w http.ResponseWriter, r *http.Request, gr
 	} else {
 		w.Header().Set("Cache-Control", "max-age=50000") // about 14 hours
 	}
-	w.Write(page)
+	writePage(w, r, page)
 }
 
-var H1, _H1 = []byte("<h1"), []byte("</h1>")
-var H2, _H2 = []byte("<h2"), []byte("</h2>")
-
-const MaxTitleLen = 256
-
-var TagSigns = [2]rune{'<', '>'}
-
-func retrieveArticleContent(group, file string) (Article, error) {
+func retrieveArticleContent(lang, group, file string) (Article, error) {
 	article := Article{}
-	content, err := loadArticleFile(group, file)
+	content, err := loadArticleFile(langGroupDir(lang, group), file)
 	if err != nil {
 		return article, err
 	}
 
+	article.Language = lang
 	article.Group = group
 	article.Filename = file
 	article.FilenameWithoutExt = strings.TrimSuffix(file, ".html")
-
-	// retrieve titles
-	splitTitleContent := func(startTag, endTag []byte, toH1 bool) (int, int) {
-		j, i := -1, bytes.Index(content, startTag)
-		if i >= 0 {
-			i += len(startTag)
-			j = bytes.Index(bytesWithLength(content[i:], MaxTitleLen), endTag)
-		}
-		if j < 0 {
-			return -1, 0
-		}
-		if toH1 {
-			content[i-1] = '1'
-			content[i+j+len(endTag)-2] = '1'
-		}
-		return i - len(startTag), i + j + len(endTag)
-	}
-
-	titleStart, contentStart := splitTitleContent(H1, _H1, false)
-	if titleStart < 0 {
-		titleStart, contentStart = splitTitleContent(H2, _H2, true)
-	}
-	article.Content = template.HTML(content)
-	if titleStart < 0 {
-		//log.Println("retrieveTitlesForArticle failed:", group, file)
-	} else {
-		article.Title = template.HTML(html.UnescapeString(string(article.Content[titleStart:contentStart])))
-
-		//article.Content = article.Content[contentStart:]
-		k, s := 0, make([]rune, 0, MaxTitleLen)
-		for _, r := range article.Title {
-			if r == TagSigns[k] {
-				k = (k + 1) & 1
-			} else if k == 0 {
-				s = append(s, r)
-			}
-		}
-		article.TitleWithoutTags = string(s)
-	}
+	parseArticle(&article, content)
 
 	return article, nil
 }
 
-func retrieveIndexContent(group string) template.HTML {
-	page101, err := retrieveArticleContent(group, "101.html")
+func retrieveIndexContent(lang, group string) (template.HTML, []Chapter) {
+	page101, err := retrieveArticleContent(lang, group, "101.html")
 	if err != nil {
 		if errors.Is(err, fs.ErrNotExist) {
-			return ""
+			return "", nil
 		}
 		panic(err)
 	}
-	content := []byte(page101.Content)
-	start := []byte("<!-- index starts (don't remove) -->")
-	i := bytes.Index(content, start)
-	if i < 0 {
-		//panic("index not found")
-		//log.Printf("index not found in %s/101/html", group)
-		return ""
-	}
-	content = content[i+len(start):]
-	end := []byte("<!-- index ends (don't remove) -->")
-	i = bytes.Index(content, end)
-	if i < 0 {
-		//panic("index not found")
-		//log.Printf("index not found in %s/101/html", group)
-		return ""
-	}
-	content = content[:i]
-	//comments := [][]byte{
-	//	[]byte("<!-- (to remove) for printing"),
-	//	[]byte("(to remove) -->"),
-	//}
-	//for _, cmt := range comments {
-	//	i = bytes.Index(content, cmt)
-	//	if i >= 0 {
-	//		filleBytes(content[i:i+len(cmt)], ' ')
-	//	}
-	//}
-	return template.HTML(content)
-}
-
-var (
-	aStart = []byte(`<a `)
-	aEnd   = []byte(`</a>`)
-	aHref  = []byte(`href="`)
-	aID    = []byte(`id="i-`)
-	// The lengths of the above two must be equal.
-)
-
-func disableArticleLink(htmlContent template.HTML, page string) (r template.HTML) {
-	content := []byte(htmlContent)
-	i := bytes.Index(content, []byte(page))
-	if i >= 0 {
-		j := bytes.LastIndex(content[:i], aHref)
-		if j >= 0 {
-			k := bytes.LastIndex(content[:j], aStart)
-			if k >= 0 {
-				l := bytes.Index(content[i+len(page):], aEnd)
-				if l >= 0 {
-					l += i + len(page)
-					content[k+1] = 'b'
-					content[l+2] = 'b'
-					copy(content[j:], aID)
-				}
-			}
-		}
-	}
-	return template.HTML(content)
+	index := extractIndexContent([]byte(page101.Content))
+	return template.HTML(index), extractChapters(index)
 }
 
 //===================================================
@@ -308,6 +353,10 @@ const (
 	Template_Article PageTemplate = iota
 	Template_GoGet
 	Template_Redirect
+	Template_I18nStatus
+	Template_Topics
+	Template_Me
+	Template_QuizReview
 	NumPageTemplates
 )
 
@@ -338,6 +387,14 @@ func retrievePageTemplate(which PageTemplate, cacheIt bool) *template.Template {
 			t = parseTemplate(pageTemplatesCommonPaths, "go-get")
 		case Template_Redirect:
 			t = parseTemplate(pageTemplatesCommonPaths, "redirect")
+		case Template_I18nStatus:
+			t = parseTemplate(pageTemplatesCommonPaths, "i18n-status")
+		case Template_Topics:
+			t = parseTemplate(pageTemplatesCommonPaths, "topics")
+		case Template_Me:
+			t = parseTemplate(pageTemplatesCommonPaths, "me")
+		case Template_QuizReview:
+			t = parseTemplate(pageTemplatesCommonPaths, "quiz-review")
 		default:
 			t = template.New("blank")
 		}
@@ -367,18 +424,25 @@ var dummyHandler http.Handler = http.HandlerFunc(func(http.ResponseWriter, *http
 
 var staticFilesHandler_NonEmbedding = http.FileServer(http.Dir(filepath.Join(rootPath, "web", "static")))
 
-func collectPageGroups_NonEmbedding() map[string]*PageGroup {
-	infos, err := os.ReadDir(filepath.Join(rootPath, "pages"))
+func collectPageGroups_NonEmbedding(lang string) map[string]*PageGroup {
+	dir := filepath.Join(rootPath, "pages")
+	if lang != DefaultLanguage {
+		dir = filepath.Join(dir, lang)
+	}
+	infos, err := os.ReadDir(dir)
 	if err != nil {
+		if lang != DefaultLanguage && errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
 		panic("collect page groups error: " + err.Error())
 	}
 
 	pageGroups := make(map[string]*PageGroup, len(infos))
 
 	for _, e := range infos {
-		if e.IsDir() {
+		if e.IsDir() && !(lang == DefaultLanguage && isTranslationFolder(e.Name())) {
 			group, handler := e.Name(), dummyHandler
-			resPath := filepath.Join(rootPath, "pages", group, "res")
+			resPath := filepath.Join(dir, group, "res")
 			if _, err := os.Stat(resPath); err == nil {
 				var urlPrefix string
 				// For history reason, fundamentals pages uses "/article/xxx" URLs.
@@ -403,6 +467,30 @@ func loadArticleFile_NonEmbedding(group, file string) ([]byte, error) {
 	return os.ReadFile(filepath.Join(rootPath, "pages", group, file))
 }
 
+func articleFileExists_NonEmbedding(group, file string) bool {
+	info, err := os.Stat(filepath.Join(rootPath, "pages", group, file))
+	return err == nil && !info.IsDir()
+}
+
+func staticFileExists_NonEmbedding(file string) bool {
+	info, err := os.Stat(filepath.Join(rootPath, "web", "static", file))
+	return err == nil && !info.IsDir()
+}
+
+func listArticleFiles_NonEmbedding(dir string) ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(rootPath, "pages", dir))
+	if err != nil {
+		return nil, err
+	}
+	files := make([]string, 0, len(entries))
+	for _, e := range entries {
+		if !e.IsDir() {
+			files = append(files, e.Name())
+		}
+	}
+	return files, nil
+}
+
 func parseTemplate_NonEmbedding(commonPaths []string, files ...string) *template.Template {
 	cp := filepath.Join(commonPaths...)
 	ts := make([]string, len(files))
@@ -442,13 +530,6 @@ func findGo101ProjectRoot() (string, bool) {
 // utils
 //===================================================
 
-func bytesWithLength(s []byte, n int) []byte {
-	if n > len(s) {
-		n = len(s)
-	}
-	return s[:n]
-}
-
 func filleBytes(s []byte, b byte) {
 	for i := range s {
 		s[i] = b
@@ -479,6 +560,49 @@ func isLocalRequest(r *http.Request) bool {
 	return hostname == "localhost" // || hostname == "127.0.0.1" // 127.* for local cached version now
 }
 
+// isLoopbackRequest reports whether or not r is sent from the local
+// machine directly (not forwarded by proxies).
+func isLoopbackRequest(r *http.Request) bool {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return false
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback() &&
+		r.Header.Get("X-Forwarded-For") == "" && r.Header.Get("X-Real-IP") == ""
+}
+
+// isLoopbackHostRequest reports whether or not r is sent from the local
+// machine directly to a loopback host, so that pages of other sites
+// (through DNS rebinding) can't use the local-only services.
+func isLoopbackHostRequest(r *http.Request) bool {
+	if !isLoopbackRequest(r) {
+		return false
+	}
+	host := r.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.Trim(host, "[]")
+	ip := net.ParseIP(host)
+	return host == "localhost" || ip != nil && ip.IsLoopback()
+}
+
+// isSameOriginJSONRequest reports whether or not r is a JSON request
+// sent by the scripts of the pages of this site. Only same-origin
+// scripts can send JSON requests (without CORS).
+func isSameOriginJSONRequest(r *http.Request) bool {
+	if !strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		return false
+	}
+	if origin := r.Header.Get("Origin"); origin != "" {
+		if u, err := url.Parse(origin); err != nil || u.Host != r.Host {
+			return false
+		}
+	}
+	return true
+}
+
 func runShellCommand(timeout time.Duration, wd string, cmd string, args ...string) ([]byte, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
@@ -509,11 +633,18 @@ func goGet(pkgPath, wd string) {
 // cache
 //===================================================
 
+// Cache keys are pairs of strings. The keys with the same first parts
+// are viewed as the same pattern. The number of cached pages of each
+// pattern is limited, so that requests for arbitrarily many distinct
+// URLs don't make the cache grow forever.
 type Cache struct {
 	sync.Mutex
-	pages map[[2]string][]byte
+	pages        map[[2]string][]byte
+	patternSizes map[string]int
 }
 
+const MaxCacheKeysPerPattern = 1024
+
 func (c *Cache) Get(group, name string) []byte {
 	c.Lock()
 	defer c.Unlock()
@@ -521,17 +652,33 @@ func (c *Cache) Get(group, name string) []byte {
 	return page
 }
 
+// Set caches a page. If the pattern of the key has
+// reached its limit, the page is not cached. Blank pages
+// (for not found pages) are never cached, so that requests
+// for junk URLs don't take the slots of real pages.
 func (c *Cache) Set(group, name string, page []byte) {
+	if len(page) == 0 {
+		return
+	}
 	c.Lock()
 	defer c.Unlock()
 	if c.pages == nil {
 		c.pages = map[[2]string][]byte{}
+		c.patternSizes = map[string]int{}
+	}
+	key := [2]string{group, name}
+	if _, ok := c.pages[key]; !ok {
+		if c.patternSizes[group] >= MaxCacheKeysPerPattern {
+			return
+		}
+		c.patternSizes[group]++
 	}
-	c.pages[[2]string{group, name}] = page
+	c.pages[key] = page
 }
 
 func (c *Cache) Clear() {
 	c.Lock()
 	defer c.Unlock()
 	c.pages = map[[2]string][]byte{}
+	c.patternSizes = map[string]int{}
 }
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"bytes"
+	"html"
+	"strings"
+)
+
+// A minimal HTML tokenizer, which is enough for the pages generated by
+// tmd and ebooktool. It keeps the byte offsets of tokens, so that the
+// content can be modified precisely at token boundaries.
+
+type htmlTokenType int
+
+const (
+	htmlTextToken htmlTokenType = iota
+	htmlStartTagToken
+	htmlEndTagToken
+	htmlSelfClosingTagToken
+	htmlCommentToken
+	htmlDoctypeToken
+)
+
+type htmlToken struct {
+	Type       htmlTokenType
+	Data       string // lower-cased tag name, raw text or comment
+	Attrs      []htmlAttr
+	Start, End int // byte offsets in the source
+	Line       int // 1-based
+}
+
+type htmlAttr struct {
+	Key, Val string // Val is unescaped
+}
+
+func (t *htmlToken) Attr(key string) (string, bool) {
+	for _, a := range t.Attrs {
+		if a.Key == key {
+			return a.Val, true
+		}
+	}
+	return "", false
+}
+
+func (t *htmlToken) HasClass(class string) bool {
+	classes, _ := t.Attr("class")
+	for _, c := range strings.Fields(classes) {
+		if c == class {
+			return true
+		}
+	}
+	return false
+}
+
+// The content of these elements is not parsed as HTML.
+var rawTextElements = map[string]bool{"script": true, "style": true}
+
+type htmlTokenizer struct {
+	src     []byte
+	pos     int
+	line    int
+	rawText string // the raw text element being in
+}
+
+func newHTMLTokenizer(src []byte) *htmlTokenizer {
+	return &htmlTokenizer{src: src, line: 1}
+}
+
+// Next returns the next token. The second result is false at the end.
+func (z *htmlTokenizer) Next() (htmlToken, bool) {
+	if z.pos >= len(z.src) {
+		return htmlToken{}, false
+	}
+
+	t := htmlToken{Start: z.pos, Line: z.line}
+	s := z.src[z.pos:]
+	switch {
+	case z.rawText != "":
+		end := indexFold(s, "</"+z.rawText)
+		if end < 0 {
+			end = len(s)
+		}
+		z.rawText = ""
+		if end == 0 {
+			return z.Next()
+		}
+		t.Type, t.Data = htmlTextToken, string(s[:end])
+		z.advance(end)
+	case bytes.HasPrefix(s, []byte("<!--")):
+		end := bytes.Index(s[4:], []byte("-->"))
+		if end < 0 {
+			t.Data = string(s[4:])
+			end = len(s)
+		} else {
+			t.Data = string(s[4 : 4+end])
+			end += 4 + 3
+		}
+		t.Type = htmlCommentToken
+		z.advance(end)
+	case bytes.HasPrefix(s, []byte("<!")) || bytes.HasPrefix(s, []byte("<?")):
+		end := bytes.IndexByte(s, '>') + 1
+		if end <= 0 {
+			end = len(s)
+		}
+		t.Type, t.Data = htmlDoctypeToken, string(bytes.TrimSuffix(s[2:end], []byte(">")))
+		z.advance(end)
+	case len(s) > 2 && s[0] == '<' && s[1] == '/' && isASCIILetter(s[2]):
+		end := bytes.IndexByte(s, '>') + 1
+		if end <= 0 {
+			end = len(s)
+		}
+		name := s[2:]
+		if i := bytes.IndexAny(name, " \t\r\n/>"); i >= 0 {
+			name = name[:i]
+		}
+		t.Type, t.Data = htmlEndTagToken, strings.ToLower(string(name))
+		z.advance(end)
+	case len(s) > 1 && s[0] == '<' && isASCIILetter(s[1]):
+		z.parseTag(&t)
+	default:
+		end := bytes.IndexByte(s[1:], '<') + 1
+		if end <= 0 {
+			end = len(s)
+		}
+		t.Type, t.Data = htmlTextToken, string(s[:end])
+		z.advance(end)
+	}
+	t.End = z.pos
+	return t, true
+}
+
+func (z *htmlTokenizer) parseTag(t *htmlToken) {
+	s := z.src[z.pos:]
+	i := 1
+	for i < len(s) && !isHTMLSpace(s[i]) && s[i] != '>' && s[i] != '/' {
+		i++
+	}
+	t.Type, t.Data = htmlStartTagToken, strings.ToLower(string(s[1:i]))
+
+	for i < len(s) {
+		for i < len(s) && isHTMLSpace(s[i]) {
+			i++
+		}
+		if i >= len(s) {
+			break
+		}
+		if s[i] == '>' {
+			i++
+			break
+		}
+		if s[i] == '/' {
+			if i+1 < len(s) && s[i+1] == '>' {
+				t.Type = htmlSelfClosingTagToken
+				i += 2
+				break
+			}
+			i++
+			continue
+		}
+
+		k := i
+		for i < len(s) && !isHTMLSpace(s[i]) && s[i] != '=' && s[i] != '>' && !(s[i] == '/' && i+1 < len(s) && s[i+1] == '>') {
+			i++
+		}
+		attr := htmlAttr{Key: strings.ToLower(string(s[k:i]))}
+		for i < len(s) && isHTMLSpace(s[i]) {
+			i++
+		}
+		if i < len(s) && s[i] == '=' {
+			i++
+			for i < len(s) && isHTMLSpace(s[i]) {
+				i++
+			}
+			if i < len(s) && (s[i] == '"' || s[i] == '\'') {
+				q := s[i]
+				i++
+				v := i
+				for i < len(s) && s[i] != q {
+					i++
+				}
+				attr.Val = html.UnescapeString(string(s[v:i]))
+				if i < len(s) {
+					i++
+				}
+			} else {
+				v := i
+				for i < len(s) && !isHTMLSpace(s[i]) && s[i] != '>' {
+					i++
+				}
+				attr.Val = html.UnescapeString(string(s[v:i]))
+			}
+		}
+		t.Attrs = append(t.Attrs, attr)
+	}
+
+	if t.Type == htmlStartTagToken && rawTextElements[t.Data] {
+		z.rawText = t.Data
+	}
+	z.advance(i)
+}
+
+func (z *htmlTokenizer) advance(n int) {
+	z.line += bytes.Count(z.src[z.pos:z.pos+n], []byte{'\n'})
+	z.pos += n
+}
+
+func isASCIILetter(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+func isHTMLSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
+
+// indexFold is like bytes.Index, but ASCII case-insensitive.
+func indexFold(s []byte, sub string) int {
+	b := []byte(sub)
+	for i := 0; i+len(b) <= len(s); i++ {
+		if bytes.EqualFold(s[i:i+len(b)], b) {
+			return i
+		}
+	}
+	return -1
+}
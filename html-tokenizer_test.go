@@ -0,0 +1,114 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func tokenize(src string) []htmlToken {
+	var tokens []htmlToken
+	z := newHTMLTokenizer([]byte(src))
+	for {
+		t, ok := z.Next()
+		if !ok {
+			return tokens
+		}
+		tokens = append(tokens, t)
+	}
+}
+
+func TestHTMLTokenizerAttrs(t *testing.T) {
+	tokens := tokenize(`<A HREF="x.html#a" class='c1  c2' data-x=1&amp;2 checked title="a 'b' &lt;c&gt;" alt='say "hi"'/>`)
+	if len(tokens) != 1 {
+		t.Fatalf("got %d tokens, want 1", len(tokens))
+	}
+	tok := tokens[0]
+	if tok.Type != htmlSelfClosingTagToken || tok.Data != "a" {
+		t.Errorf("got type %d and name %q, want a self-closing a tag", tok.Type, tok.Data)
+	}
+	want := []htmlAttr{
+		{"href", "x.html#a"},
+		{"class", "c1  c2"},
+		{"data-x", "1&2"},
+		{"checked", ""},
+		{"title", "a 'b' <c>"},
+		{"alt", `say "hi"`},
+	}
+	if !reflect.DeepEqual(tok.Attrs, want) {
+		t.Errorf("got attrs %q, want %q", tok.Attrs, want)
+	}
+	if !tok.HasClass("c2") || tok.HasClass("c") {
+		t.Errorf("HasClass is wrong for %q", want[1].Val)
+	}
+	if _, ok := tok.Attr("checked"); !ok {
+		t.Errorf("the checked attribute is not found")
+	}
+}
+
+func TestHTMLTokenizerRawText(t *testing.T) {
+	for _, src := range []string{
+		`<script>if (a<b && c>d) { x = "</p>" }</script><p>`,
+		`<STYLE>a > b { content: "<b>" }</Style><p>`,
+	} {
+		tokens := tokenize(src)
+		if len(tokens) != 4 {
+			t.Errorf("%s: got %d tokens, want 4", src, len(tokens))
+			continue
+		}
+		if tokens[1].Type != htmlTextToken || tokens[2].Type != htmlEndTagToken || tokens[3].Data != "p" {
+			t.Errorf("%s: the raw text is not kept as a single text token: %+v", src, tokens)
+		}
+	}
+
+	// An unterminated raw text element takes the rest.
+	tokens := tokenize(`<script>a < b <p>`)
+	if len(tokens) != 2 || tokens[1].Data != "a < b <p>" {
+		t.Errorf("unterminated script: got %+v", tokens)
+	}
+}
+
+func TestHTMLTokenizerUnterminated(t *testing.T) {
+	for _, c := range []struct {
+		src  string
+		typ  htmlTokenType
+		data string
+	}{
+		{"<!-- a <b> c", htmlCommentToken, " a <b> c"},
+		{"<!-- a -- b -->", htmlCommentToken, " a -- b "},
+		{`<a href="x`, htmlStartTagToken, "a"},
+		{"</p", htmlEndTagToken, "p"},
+		{"<!DOCTYPE html", htmlDoctypeToken, "DOCTYPE html"},
+		{"a < b", htmlTextToken, "a "},
+	} {
+		tokens := tokenize(c.src)
+		if len(tokens) == 0 || tokens[0].Type != c.typ || tokens[0].Data != c.data {
+			t.Errorf("%q: got %+v", c.src, tokens)
+			continue
+		}
+		if end := tokens[len(tokens)-1].End; end != len(c.src) {
+			t.Errorf("%q: the tokens end at %d, want %d", c.src, end, len(c.src))
+		}
+	}
+}
+
+func TestHTMLTokenizerLines(t *testing.T) {
+	src := "<h1>T</h1>\n<!-- 1\n2 -->\n<p\n class=x>a\nb</p>\n<script>\n\n</script><pre><code>c\n</code></pre>"
+	var got []int
+	var offsets [][2]int
+	for _, tok := range tokenize(src) {
+		if tok.Type != htmlTextToken {
+			got = append(got, tok.Line)
+		}
+		offsets = append(offsets, [2]int{tok.Start, tok.End})
+	}
+	// h1 /h1 comment p /p script /script pre code /code /pre
+	want := []int{1, 1, 2, 4, 6, 7, 9, 9, 9, 10, 10}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got lines %v, want %v", got, want)
+	}
+	for i := 1; i < len(offsets); i++ {
+		if offsets[i][0] != offsets[i-1][1] {
+			t.Errorf("token %d starts at %d, but the previous one ends at %d", i, offsets[i][0], offsets[i-1][1])
+		}
+	}
+}
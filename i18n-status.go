@@ -0,0 +1,264 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// A translated page records the English source it was translated from
+// with the following marks, which may be put in HTML comments:
+//
+//	source-hash: <sha256 hex prefix of the English source file>
+//	source-commit: <git commit hash of the English source file>
+//
+// The English source file is the .tmd or .md file of an article
+// if it exists, otherwise the .html file.
+var translationMarkRegexp = regexp.MustCompile(`source-(hash|commit):\s*([0-9a-fA-F]{7,64})`)
+
+var (
+	headingRegexp   = regexp.MustCompile(`<h[1-6][\s>]`)
+	codeBlockRegexp = regexp.MustCompile(`<pre[\s>]`)
+)
+
+type TranslationReport struct {
+	Lang, LangName string
+	Groups         []GroupTranslationReport
+}
+
+type GroupTranslationReport struct {
+	Group             string
+	Total, Translated int
+	Missing           []string
+	Outdated          []TranslationIssue
+	Mismatched        []TranslationIssue
+	Unverifiable      []TranslationIssue // no source marks
+}
+
+type TranslationIssue struct {
+	File, Reason string
+}
+
+func (gr *GroupTranslationReport) HasIssues() bool {
+	return len(gr.Missing)+len(gr.Outdated)+len(gr.Mismatched)+len(gr.Unverifiable) > 0
+}
+
+func (go101 *Go101) translationReports(onlyLang string) []TranslationReport {
+	langs := make([]string, 0, len(go101.translations))
+	for lang := range go101.translations {
+		if onlyLang == "" || lang == onlyLang {
+			langs = append(langs, lang)
+		}
+	}
+	sort.Strings(langs)
+
+	groups := make([]string, 0, len(go101.pageGroups))
+	for group := range go101.pageGroups {
+		groups = append(groups, group)
+	}
+	sort.Strings(groups)
+
+	reports := make([]TranslationReport, 0, len(langs))
+	for _, lang := range langs {
+		report := TranslationReport{Lang: lang, LangName: languages[lang].Name}
+		for _, group := range groups {
+			report.Groups = append(report.Groups, groupTranslationReport(lang, group))
+		}
+		reports = append(reports, report)
+	}
+	return reports
+}
+
+func groupTranslationReport(lang, group string) GroupTranslationReport {
+	gr := GroupTranslationReport{Group: group}
+	files, _ := listArticleFiles(group)
+	for _, file := range files {
+		if !strings.HasSuffix(file, ".html") {
+			continue
+		}
+		gr.Total++
+
+		content, err := loadArticleFile(langGroupDir(lang, group), file)
+		if err != nil {
+			gr.Missing = append(gr.Missing, file)
+			continue
+		}
+		gr.Translated++
+
+		if reason := translationMismatch(group, file, content); reason != "" {
+			gr.Mismatched = append(gr.Mismatched, TranslationIssue{file, reason})
+		}
+
+		outdated, reason := isTranslationOutdated(lang, group, file, content)
+		if reason == "" {
+			continue
+		}
+		issue := TranslationIssue{file, reason}
+		if outdated {
+			gr.Outdated = append(gr.Outdated, issue)
+		} else {
+			gr.Unverifiable = append(gr.Unverifiable, issue)
+		}
+	}
+	return gr
+}
+
+func translationMismatch(group, file string, translated []byte) string {
+	source, err := loadArticleFile(group, file)
+	if err != nil {
+		return ""
+	}
+
+	var diffs []string
+	sh, th := len(headingRegexp.FindAllIndex(source, -1)), len(headingRegexp.FindAllIndex(translated, -1))
+	if sh != th {
+		diffs = append(diffs, fmt.Sprintf("%d headings vs %d in source", th, sh))
+	}
+	sc, tc := len(codeBlockRegexp.FindAllIndex(source, -1)), len(codeBlockRegexp.FindAllIndex(translated, -1))
+	if sc != tc {
+		diffs = append(diffs, fmt.Sprintf("%d code blocks vs %d in source", tc, sc))
+	}
+	return strings.Join(diffs, ", ")
+}
+
+// articleSourceFile returns the name of the file the HTML file of
+// an article is generated from, or the HTML file itself.
+func articleSourceFile(dir, htmlFile string) string {
+	base := strings.TrimSuffix(htmlFile, ".html")
+	for _, ext := range []string{".tmd", ".md"} {
+		if articleFileExists(dir, base+ext) {
+			return base + ext
+		}
+	}
+	return htmlFile
+}
+
+// isTranslationOutdated compares the source marks recorded in a translated
+// page with the current English source. The returned reason is blank if
+// the translation is up to date.
+func isTranslationOutdated(lang, group, file string, translated []byte) (outdated bool, reason string) {
+	// The marks might be only recorded in the source of the translation.
+	tsource := articleSourceFile(langGroupDir(lang, group), file)
+	if tsource != file {
+		if content, err := loadArticleFile(langGroupDir(lang, group), tsource); err == nil {
+			translated = append(content, translated...)
+		}
+	}
+
+	var recordedHash, recordedCommit string
+	for _, m := range translationMarkRegexp.FindAllSubmatch(translated, -1) {
+		if string(m[1]) == "hash" {
+			recordedHash = strings.ToLower(string(m[2]))
+		} else {
+			recordedCommit = strings.ToLower(string(m[2]))
+		}
+	}
+	if recordedHash == "" && recordedCommit == "" {
+		return false, "no source-hash or source-commit marks"
+	}
+
+	source := articleSourceFile(group, file)
+	if recordedHash != "" {
+		content, err := loadArticleFile(group, source)
+		if err != nil {
+			return false, err.Error()
+		}
+		sum := sha256.Sum256(content)
+		if hash := hex.EncodeToString(sum[:]); !strings.HasPrefix(hash, recordedHash) {
+			return true, fmt.Sprintf("%s changed (hash %.12s, recorded %.12s)", source, hash, recordedHash)
+		}
+		return false, ""
+	}
+
+	if !wdIsGo101ProjectRoot {
+		return false, "git commits can't be checked for embedded pages"
+	}
+	sourcePath := filepath.Join("pages", group, source)
+	output, err := runShellCommand(time.Minute/2, rootPath, "git", "log", "-1", "--format=%H", "--", sourcePath)
+	if err != nil {
+		return false, fmt.Sprintf("git log: %s", err)
+	}
+	latest := string(bytes.TrimSpace(output))
+	if latest == "" || strings.HasPrefix(latest, recordedCommit) {
+		return false, ""
+	}
+	// Up to date if the latest commit is an ancestor of the recorded one.
+	_, err = runShellCommand(time.Minute/2, rootPath, "git", "merge-base", "--is-ancestor", latest, recordedCommit)
+	if err == nil {
+		return false, ""
+	}
+	return true, fmt.Sprintf("%s changed in commit %.12s (recorded %.12s)", source, latest, recordedCommit)
+}
+
+func runI18nStatus(args []string) int {
+	flags := flag.NewFlagSet("i18n-status", flag.ExitOnError)
+	langFlag := flags.String("lang", "", "only report this language")
+	verboseFlag := flags.Bool("v", false, "also list pages without source marks")
+	flags.Parse(args)
+
+	reports := go101.translationReports(*langFlag)
+	if len(reports) == 0 {
+		fmt.Println("No translations found (translations are put in pages/<lang>/<group> folders).")
+		return 0
+	}
+
+	w, numIssues := os.Stdout, 0
+	for _, report := range reports {
+		fmt.Fprintf(w, "%s (%s)\n", report.Lang, report.LangName)
+		for _, gr := range report.Groups {
+			fmt.Fprintf(w, "  %s: %d/%d translated\n", gr.Group, gr.Translated, gr.Total)
+			// Groups not being translated are not viewed as issues.
+			if len(gr.Missing) > 0 && gr.Translated > 0 {
+				fmt.Fprintf(w, "    missing: %s\n", strings.Join(gr.Missing, " "))
+				numIssues += len(gr.Missing)
+			}
+			numIssues += len(gr.Outdated) + len(gr.Mismatched)
+			for _, issue := range gr.Outdated {
+				fmt.Fprintf(w, "    outdated: %s: %s\n", issue.File, issue.Reason)
+			}
+			for _, issue := range gr.Mismatched {
+				fmt.Fprintf(w, "    differs: %s: %s\n", issue.File, issue.Reason)
+			}
+			if *verboseFlag {
+				for _, issue := range gr.Unverifiable {
+					fmt.Fprintf(w, "    unknown: %s: %s\n", issue.File, issue.Reason)
+				}
+			}
+		}
+	}
+	if numIssues > 0 {
+		return 1
+	}
+	return 0
+}
+
+// ServeI18nStatusPage serves the translation status report.
+// It is only available to requests from the local machine.
+func (go101 *Go101) ServeI18nStatusPage(w http.ResponseWriter, r *http.Request) {
+	if !isLoopbackRequest(r) {
+		http.NotFound(w, r)
+		return
+	}
+
+	pageParams := map[string]any{
+		"Reports": go101.translationReports(r.FormValue("lang")),
+		"Theme":   go101.theme,
+	}
+	var buf bytes.Buffer
+	t := retrievePageTemplate(Template_I18nStatus, false)
+	if err := t.Execute(&buf, pageParams); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Cache-Control", "no-cache, private, max-age=0")
+	w.Write(buf.Bytes())
+}
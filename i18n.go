@@ -0,0 +1,213 @@
+package main
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// English pages are put in pages/<group> folders.
+// Translated pages are put in pages/<lang>/<group> folders.
+const DefaultLanguage = "en"
+
+type Language struct {
+	Name               string
+	UntranslatedNotice string
+}
+
+// The folders of these names in the pages folder are
+// viewed as translation folders instead of page groups.
+var languages = map[string]Language{
+	"en": {
+		Name: "English",
+	},
+	"zh": {
+		Name:               "中文",
+		UntranslatedNotice: "本文尚未被翻译，下面显示的是英文原文。",
+	},
+	"ja": {
+		Name:               "日本語",
+		UntranslatedNotice: "このページはまだ翻訳されていません。英語の原文を表示しています。",
+	},
+	"ko": {
+		Name:               "한국어",
+		UntranslatedNotice: "이 페이지는 아직 번역되지 않았습니다. 영어 원문을 표시합니다.",
+	},
+	"es": {
+		Name:               "Español",
+		UntranslatedNotice: "Esta página aún no está traducida. Se muestra la versión en inglés.",
+	},
+	"ru": {
+		Name:               "Русский",
+		UntranslatedNotice: "Эта страница ещё не переведена. Показана английская версия.",
+	},
+}
+
+const LanguageCookieName = "lang"
+
+func isTranslationFolder(name string) bool {
+	_, ok := languages[name]
+	return ok && name != DefaultLanguage
+}
+
+// langGroupDir returns the folder (relative to the pages folder)
+// of the pages of a group in the specified language.
+func langGroupDir(lang, group string) string {
+	if lang == DefaultLanguage {
+		return group
+	}
+	return lang + "/" + group
+}
+
+func langURLPrefix(lang string) string {
+	if lang == DefaultLanguage {
+		return ""
+	}
+	return "/" + lang
+}
+
+// isServedLanguage reports whether or not the pages
+// in the specified language are served.
+func (go101 *Go101) isServedLanguage(lang string) bool {
+	if lang == DefaultLanguage {
+		return true
+	}
+	_, ok := go101.translations[lang]
+	return ok
+}
+
+// splitLanguagePrefix splits a URL path into a language and the path
+// without the language prefix. The returned language is blank if the
+// path has no language prefixes.
+func (go101 *Go101) splitLanguagePrefix(urlPath string) (lang, rest string) {
+	if len(urlPath) < 2 {
+		return "", urlPath
+	}
+	lang, rest = urlPath[1:], "/"
+	if i := strings.IndexByte(lang, '/'); i >= 0 {
+		lang, rest = lang[:i], lang[i:]
+	}
+	if !go101.isServedLanguage(lang) {
+		return "", urlPath
+	}
+	return lang, rest
+}
+
+// preferredLanguage returns the language chosen by the client, through
+// the language cookie or the Accept-Language header. Only served
+// languages are considered.
+func (go101 *Go101) preferredLanguage(r *http.Request) string {
+	if c, err := r.Cookie(LanguageCookieName); err == nil && go101.isServedLanguage(c.Value) {
+		return c.Value
+	}
+
+	type weightedLang struct {
+		lang string
+		q    float64
+	}
+	var wls []weightedLang
+	for _, part := range strings.Split(r.Header.Get("Accept-Language"), ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if params = strings.TrimSpace(params); strings.HasPrefix(params, "q=") {
+			if f, err := strconv.ParseFloat(params[2:], 64); err == nil {
+				q = f
+			}
+		}
+		primary, _, _ := strings.Cut(strings.ToLower(tag), "-")
+		if go101.isServedLanguage(primary) && q > 0 {
+			wls = append(wls, weightedLang{primary, q})
+		}
+	}
+	sort.SliceStable(wls, func(i, j int) bool { return wls[i].q > wls[j].q })
+	if len(wls) > 0 {
+		return wls[0].lang
+	}
+	return DefaultLanguage
+}
+
+// RememberLanguage records the language explicitly
+// selected by a URL prefix in the language cookie.
+func (go101 *Go101) RememberLanguage(w http.ResponseWriter, r *http.Request) {
+	lang, _ := go101.splitLanguagePrefix(r.URL.Path)
+	if lang == "" {
+		return
+	}
+	if c, err := r.Cookie(LanguageCookieName); err == nil && c.Value == lang {
+		return
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     LanguageCookieName,
+		Value:    lang,
+		Path:     "/",
+		MaxAge:   365 * 24 * 3600,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// RedirectToPreferredLanguage redirects a request for an English
+// article page to its translation in the language preferred by the
+// client, if the translation exists. It returns whether or not the
+// request has been redirected.
+func (go101 *Go101) RedirectToPreferredLanguage(w http.ResponseWriter, r *http.Request, group, file string) bool {
+	if !strings.HasSuffix(file, ".html") {
+		return false
+	}
+
+	w.Header().Add("Vary", "Accept-Language, Cookie")
+	lang := go101.preferredLanguage(r)
+	if lang == DefaultLanguage || !articleFileExists(langGroupDir(lang, group), file) {
+		return false
+	}
+
+	target := langURLPrefix(lang) + r.URL.Path
+	if r.URL.RawQuery != "" {
+		target += "?" + r.URL.RawQuery
+	}
+	http.Redirect(w, r, target, http.StatusFound)
+	return true
+}
+
+type LanguageAlternate struct {
+	Lang, Name string
+	URL        string // canonical
+	SwitchURL  string // with explicit language prefix
+}
+
+// languageAlternates returns the languages in which an article is available.
+func (go101 *Go101) languageAlternates(group, file string) []LanguageAlternate {
+	langs := make([]string, 0, len(go101.translations)+1)
+	for lang := range go101.translations {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	langs = append([]string{DefaultLanguage}, langs...)
+
+	alternates := make([]LanguageAlternate, 0, len(langs))
+	for _, lang := range langs {
+		if !articleFileExists(langGroupDir(lang, group), file) {
+			continue
+		}
+		alternates = append(alternates, LanguageAlternate{
+			Lang:      lang,
+			Name:      languages[lang].Name,
+			URL:       canonicalArticleURL(lang, group, file),
+			SwitchURL: "/" + lang + articlePath(group, file),
+		})
+	}
+	return alternates
+}
+
+// indexPageGroup returns the page group providing the index of the
+// articles of a group in the specified language. If the group is not
+// translated or its index is not translated, the English one is used.
+func (go101 *Go101) indexPageGroup(lang, group string) *PageGroup {
+	if pg := go101.translations[lang][group]; pg != nil && pg.indexContent != "" {
+		return pg
+	}
+	if pg := go101.pageGroups[group]; pg != nil {
+		return pg
+	}
+	return &PageGroup{}
+}
@@ -0,0 +1,276 @@
+package main
+
+import (
+	"html"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// The link graph of the (English) articles in all groups, and the text
+// similarities between them. It is built on first use (see articleLinkGraph).
+// Redirected and untitled pages (such as "Moved to" stubs) are not in it.
+type LinkGraph struct {
+	nodes map[articleKey]*graphNode
+}
+
+type articleKey struct {
+	Group, File string
+}
+
+type graphNode struct {
+	articleKey
+	title     string
+	links     []articleKey // outgoing, without duplicates
+	backlinks []articleKey
+	terms     map[string]float64 // normalized TF-IDF weights
+	related   []articleKey       // the most similar ones first
+}
+
+const (
+	MaxRelatedArticles   = 5
+	MinArticleSimilarity = 0.12
+)
+
+// Words ignored when computing text similarities.
+var stopWords = func() map[string]bool {
+	m := map[string]bool{}
+	for _, w := range strings.Fields(`
+		the and are for not but can its has have had was were will would
+		should could may might must this that these those with from into
+		than then them they their there here what which when where who whom
+		how why all any both each few more most other some such only own same
+		also just very too one two use used using does did done been being
+		about above after again against below between during before under
+		over out off via let like get got make makes made
+		value values type types code example following go`) {
+		m[w] = true
+	}
+	return m
+}()
+
+// articleLinkGraph returns the link graph, which is built on first use.
+func (go101 *Go101) articleLinkGraph() *LinkGraph {
+	go101.linkGraphOnce.Do(func() {
+		go101.linkGraph = go101.buildLinkGraph()
+	})
+	return go101.linkGraph
+}
+
+func (go101 *Go101) buildLinkGraph() *LinkGraph {
+	g := &LinkGraph{nodes: map[articleKey]*graphNode{}}
+	contents := map[articleKey]string{}
+
+	for group := range go101.pageGroups {
+		if group == "website" {
+			continue
+		}
+		files, err := listArticleFiles(group)
+		if err != nil {
+			log.Printf("list files of group %s error: %s", group, err)
+			continue
+		}
+		for _, file := range files {
+			if !strings.HasSuffix(file, ".html") || !isListedInTopics(file) {
+				continue
+			}
+			if _, redirected := redirectPages[[2]string{group, file}]; redirected {
+				continue
+			}
+			article, err := retrieveArticleContent(DefaultLanguage, group, file)
+			if err != nil {
+				log.Printf("load article %s/%s error: %s", group, file, err)
+				continue
+			}
+			if article.TitleWithoutTags == "" {
+				continue
+			}
+			key := articleKey{group, file}
+			node := &graphNode{articleKey: key, title: article.TitleWithoutTags}
+			seen := map[articleKey]bool{key: true}
+			for _, link := range article.Links {
+				tg, tf, _, ok := go101.resolveArticleHref(group, link.Href)
+				if to, redirected := redirectPages[[2]string{tg, tf}]; redirected {
+					tg, tf = to[0], to[1]
+				}
+				target := articleKey{tg, tf}
+				if ok && tf != "" && !seen[target] {
+					seen[target] = true
+					node.links = append(node.links, target)
+				}
+			}
+			g.nodes[key] = node
+			contents[key] = string(article.Content)
+		}
+	}
+
+	keys := g.sortedKeys()
+	for _, key := range keys {
+		for _, target := range g.nodes[key].links {
+			if t := g.nodes[target]; t != nil {
+				t.backlinks = append(t.backlinks, key)
+			}
+		}
+	}
+
+	// Compute TF-IDF vectors and the most similar articles.
+	counts := make(map[articleKey]map[string]int, len(keys))
+	df := map[string]int{}
+	for _, key := range keys {
+		c := countTerms(g.nodes[key].title+" "+g.nodes[key].title, contents[key])
+		counts[key] = c
+		for term := range c {
+			df[term]++
+		}
+	}
+	for _, key := range keys {
+		var total int
+		for _, n := range counts[key] {
+			total += n
+		}
+		terms, norm := make(map[string]float64, len(counts[key])), 0.0
+		for term, n := range counts[key] {
+			w := float64(n) / float64(total) * math.Log(float64(len(keys))/float64(df[term]))
+			if w > 0 {
+				terms[term] = w
+				norm += w * w
+			}
+		}
+		norm = math.Sqrt(norm)
+		for term := range terms {
+			terms[term] /= norm
+		}
+		g.nodes[key].terms = terms
+	}
+	for _, key := range keys {
+		type scored struct {
+			key   articleKey
+			score float64
+		}
+		var candidates []scored
+		node := g.nodes[key]
+		for _, other := range keys {
+			if other == key {
+				continue
+			}
+			if s := cosineSimilarity(node.terms, g.nodes[other].terms); s >= MinArticleSimilarity {
+				candidates = append(candidates, scored{other, s})
+			}
+		}
+		sort.SliceStable(candidates, func(i, j int) bool {
+			return candidates[i].score > candidates[j].score
+		})
+		for i := 0; i < len(candidates) && i < MaxRelatedArticles; i++ {
+			node.related = append(node.related, candidates[i].key)
+		}
+	}
+
+	return g
+}
+
+func (g *LinkGraph) sortedKeys() []articleKey {
+	keys := make([]articleKey, 0, len(g.nodes))
+	for key := range g.nodes {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].Group != keys[j].Group {
+			return keys[i].Group < keys[j].Group
+		}
+		return keys[i].File < keys[j].File
+	})
+	return keys
+}
+
+// countTerms counts the words in the text (outside code blocks)
+// of some HTML contents.
+func countTerms(text string, htmlContent string) map[string]int {
+	counts := map[string]int{}
+	add := func(s string) {
+		for _, w := range strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+			return !unicode.IsLetter(r)
+		}) {
+			if len(w) >= 3 && !stopWords[w] {
+				counts[w]++
+			}
+		}
+	}
+	add(text)
+
+	inCode := 0
+	z := newHTMLTokenizer([]byte(htmlContent))
+	for {
+		t, ok := z.Next()
+		if !ok {
+			return counts
+		}
+		switch t.Type {
+		case htmlStartTagToken:
+			if t.Data == "pre" || t.Data == "script" || t.Data == "style" {
+				inCode++
+			}
+		case htmlEndTagToken:
+			if (t.Data == "pre" || t.Data == "script" || t.Data == "style") && inCode > 0 {
+				inCode--
+			}
+		case htmlTextToken:
+			if inCode == 0 {
+				add(html.UnescapeString(t.Data))
+			}
+		}
+	}
+}
+
+func cosineSimilarity(a, b map[string]float64) float64 {
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	var s float64
+	for term, w := range a {
+		s += w * b[term]
+	}
+	return s
+}
+
+// articleRefs returns the links to the articles in the specified language.
+// The titles of the articles are the English ones.
+func (g *LinkGraph) articleRefs(lang string, keys []articleKey) []RelatedArticle {
+	refs := make([]RelatedArticle, 0, len(keys))
+	for _, key := range keys {
+		if node := g.nodes[key]; node != nil {
+			refs = append(refs, RelatedArticle{
+				Href:  langURLPrefix(lang) + articlePath(key.Group, key.File),
+				Title: node.title,
+			})
+		}
+	}
+	return refs
+}
+
+// setArticleLinks sets the ReferencedBy and Related fields of an article.
+// The related articles specified in the metadata of the article are
+// listed before the ones found by text similarities.
+func (g *LinkGraph) setArticleLinks(article *Article, lang string) {
+	article.Related = article.Meta.Related
+	node := g.nodes[articleKey{article.Group, article.Filename}]
+	if node == nil {
+		return
+	}
+	article.ReferencedBy = g.articleRefs(lang, node.backlinks)
+
+	listed := map[string]bool{}
+	for _, r := range article.Related {
+		listed[r.Href] = true
+	}
+	for _, r := range g.articleRefs(lang, node.related) {
+		if len(article.Related) >= MaxRelatedArticles {
+			break
+		}
+		if !listed[r.Href] {
+			listed[r.Href] = true
+			article.Related = append(article.Related, r)
+		}
+	}
+}
@@ -15,25 +15,56 @@ import (
 )
 
 var portFlag = flag.String("port", "55555", "server port")
+var hostFlag = flag.String("host", "", "server host to listen on (empty means all interfaces)")
 var genFlag = flag.Bool("gen", false, "HTML generation mode?")
+var checkLinksFlag = flag.Bool("checklinks", false, "check links before generating HTML files (with -gen)")
+var checkQuizzesFlag = flag.Bool("checkquizzes", true, "check quiz answers by running the quiz programs before generating HTML files (with -gen)")
+var canonicalHostFlag = flag.String("canonicalhost", DefaultCanonicalHost, "scheme and host of the rel=canonical links of article pages")
 var themeFlag = flag.String("theme", "", "theme (dark | light)")
 var nobFlag = flag.Bool("nob", false, "not open browser?")
+var tlsCertFlag = flag.String("tlscert", "", "TLS certificate file (enables HTTPS with -tlskey)")
+var tlsKeyFlag = flag.String("tlskey", "", "TLS private key file")
+var rateLimitFlag = flag.Float64("ratelimit", 20, "max requests per second per client (0 means no limits)")
+var rateBurstFlag = flag.Int("rateburst", 100, "max burst requests per client")
+var trustedProxiesFlag = flag.String("trustedproxies", "", "comma-separated IPs/CIDRs of trusted reverse proxies")
+var playFlag = flag.Bool("play", false, "allow the local browser to run code snippets with the local Go toolchain, unsandboxed (requires a loopback -host)")
+var readerFlag = flag.Bool("reader", false, "record the reading progress, bookmarks, notes and quiz attempts on this machine (requires a loopback -host)")
+var quizStatsFlag = flag.String("quizstats", "", "file to record the aggregate choices picked in quiz pages (empty means not recorded)")
+var gorootsFlag = flag.String("goroots", "", "comma-separated GOROOTs of the toolchains to compare snippet outputs with (besides the ones cached by gotv)")
 
 var listenConfig net.ListenConfig
 
 func main() {
 	log.SetFlags(0)
+	flag.Usage = func() {
+		out := flag.CommandLine.Output()
+		fmt.Fprintf(out, "Usage: go101 [flags] [command [args]]\n\nFlags:\n")
+		flag.PrintDefaults()
+		fmt.Fprintf(out, "\n%s", commandsUsage())
+	}
 	flag.Parse()
+	canonicalHost = strings.TrimSuffix(*canonicalHostFlag, "/")
+
+	if flag.NArg() > 0 {
+		runCommand(flag.Arg(0), flag.Args()[1:])
+		return
+	}
 
 	port, isAppEngine := *portFlag, false
 	if prt := os.Getenv("PORT"); prt != "" { // appengine std
 		port = prt
 		isAppEngine = true
 	}
-	addr, err := net.ResolveTCPAddr("tcp", fmt.Sprintf(":%v", port))
+	addr, err := net.ResolveTCPAddr("tcp", net.JoinHostPort(*hostFlag, port))
 	if err != nil {
 		log.Fatal(err)
 	}
+	if *playFlag && (addr.IP == nil || !addr.IP.IsLoopback()) {
+		log.Fatal("-play runs code unsandboxed, so it needs a loopback -host, such as -host=127.0.0.1")
+	}
+	if *readerFlag && (addr.IP == nil || !addr.IP.IsLoopback()) {
+		log.Fatal("-reader serves the data of this machine, so it needs a loopback -host, such as -host=127.0.0.1")
+	}
 
 Retry:
 	//l, err := net.ListenTCP("tcp", addr)
@@ -61,10 +92,32 @@ Retry:
 		}
 
 		go updateGo101()
+
+		if *readerFlag {
+			go101.reader = newReaderStore()
+		}
+
+		if *playFlag {
+			go101.playground = newPlayground(*gorootsFlag)
+			go go101.playground.warmUp()
+		}
+	}
+
+	if !genMode && *quizStatsFlag != "" {
+		go101.quizStats, err = openQuizStats(*quizStatsFlag, parseIPNets(*trustedProxiesFlag, "trusted proxy"))
+		if err != nil {
+			log.Fatal(err)
+		}
+		go go101.quizStats.run(QuizStatsCompactInterval)
+	}
+
+	var limiter *rateLimiter
+	if !genMode && *rateLimitFlag > 0 {
+		limiter = newRateLimiter(*rateLimitFlag, *rateBurstFlag, *trustedProxiesFlag)
 	}
 
 	httpServer := &http.Server{
-		Handler:      go101,
+		Handler:      withSecurityHeaders(withRequestLimits(go101, limiter)),
 		WriteTimeout: 10 * time.Second,
 		ReadTimeout:  5 * time.Second,
 	}
@@ -73,6 +126,13 @@ Retry:
 		log.Println("Server started:")
 		log.Printf("   http://localhost:%v (non-cached version)\n", addr.Port)
 		log.Printf("   http://127.0.0.1:%v (cached version)\n", addr.Port)
+		if *tlsCertFlag != "" && *tlsKeyFlag != "" {
+			err := httpServer.ServeTLS(l, *tlsCertFlag, *tlsKeyFlag)
+			if err != nil && err != http.ErrServerClosed {
+				log.Fatal(err)
+			}
+			return
+		}
 		httpServer.Serve(l)
 	}
 
@@ -83,10 +143,25 @@ Retry:
 		if err := httpServer.Shutdown(ctx); err != nil {
 			log.Fatalf("Server shutdown error: %s", err)
 		}
+		if go101.quizStats != nil {
+			if err := go101.quizStats.Close(); err != nil {
+				log.Println(err)
+			}
+		}
 		log.Println("Server shutdown.")
 	}
 
 	if genMode {
+		if *checkLinksFlag && reportLinkIssues(go101.checkLinks(), true) != 0 {
+			log.Fatal("Broken links found. HTML files are not generated.")
+		}
+		if *checkQuizzesFlag {
+			if tc, err := localToolchain(); err != nil {
+				log.Printf("Warning: no Go toolchain is found, so quiz answers are not checked: %s", err)
+			} else if reportSnippetIssues(go101.checkQuizzes(tc, time.Minute)) != 0 {
+				log.Fatal("Wrong quiz answers found. HTML files are not generated.")
+			}
+		}
 		go runServer()
 		genStaticFiles(rootURL)
 		shutdownServer()
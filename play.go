@@ -0,0 +1,323 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The limits of running code snippets with the local toolchain.
+// A run must finish in the write timeout of the server (10s), so
+// the build caches are warmed up when the server starts (see warmUp).
+const (
+	MaxPlayCodeSize    = 64 << 10
+	MaxPlayOutputSize  = 64 << 10
+	MaxConcurrentPlays = 2
+	PlayBuildTimeout   = 5 * time.Second
+	PlayRunTimeout     = 4 * time.Second
+	PlayCPUSeconds     = 3
+	PlayMemoryLimit    = 256 << 20
+)
+
+// A playground compiles and runs code snippets submitted from the
+// "Run" buttons in article pages. Each snippet is built in a new temp
+// module with the network disabled for the go command, and the program
+// is run in the temp folder with a minimal environment and resource
+// limits (see sandboxCommand).
+//
+// The programs are not sandboxed, so the playground is only enabled
+// with the -play flag on a loopback listener, and it is only available
+// to the requests sent from the local machine directly.
+type playground struct {
+	goroots string // see discoverToolchains
+	slots   chan struct{}
+
+	once       sync.Once
+	ready      chan struct{} // closed when initialized
+	local      Toolchain     // the one of the go command in PATH
+	toolchains []Toolchain   // all discovered ones, sorted by versions
+	std        map[string]bool
+	initErr    error
+
+	jobsMu sync.Mutex
+	jobs   map[string]*matrixJob // keyed by IDs, see startMatrix
+}
+
+var errPlaygroundBusy = errors.New("the playground is busy")
+
+type PlayResult struct {
+	Output    string `json:"output"`           // stdout and stderr
+	Errors    string `json:"errors,omitempty"` // compilation errors
+	ExitCode  int    `json:"exitCode"`
+	Status    string `json:"status,omitempty"` // such as "exit status 2"
+	TimedOut  bool   `json:"timedOut,omitempty"`
+	Truncated bool   `json:"truncated,omitempty"`
+}
+
+func newPlayground(goroots string) *playground {
+	return &playground{
+		goroots: goroots,
+		slots:   make(chan struct{}, MaxConcurrentPlays),
+		ready:   make(chan struct{}),
+	}
+}
+
+// allows reports whether or not the client sending r may run code.
+// The host must be a loopback one, so that pages of other sites
+// (through DNS rebinding) can't use the playground.
+func (pg *playground) allows(r *http.Request) bool {
+	return isLoopbackHostRequest(r)
+}
+
+func (pg *playground) init() error {
+	pg.once.Do(func() {
+		defer close(pg.ready)
+		if pg.local, pg.initErr = localToolchain(); pg.initErr != nil {
+			return
+		}
+		if pg.std, pg.initErr = stdPackages(pg.local.GoCmd); pg.initErr != nil {
+			return
+		}
+		pg.toolchains = discoverToolchains(pg.goroots)
+	})
+	return pg.initErr
+}
+
+// warmUp initializes the playground and warms up the build caches of
+// the toolchains for the commonly used packages. It is called in a new
+// goroutine when the server starts, so it doesn't block the requests.
+func (pg *playground) warmUp() {
+	if err := pg.init(); err != nil {
+		log.Println("playground:", err)
+		return
+	}
+	warmup := &GoSnippet{Kind: SnippetStatements, Code: `fmt.Println(strings.ToUpper(""), time.Now(), &sync.Mutex{})`}
+	for _, tc := range pg.toolchains {
+		buildAndRunSnippet(tc, warmup, time.Minute)
+	}
+}
+
+// localToolchain returns the toolchain of the go command in PATH.
+func localToolchain() (Toolchain, error) {
+	output, err := runShellCommand(time.Minute/2, "", "go", "env", "GOROOT")
+	if err != nil {
+		return Toolchain{}, fmt.Errorf("go env GOROOT: %w: %s", err, output)
+	}
+	tc, ok := newToolchain(string(bytes.TrimSpace(output)))
+	if !ok {
+		return tc, fmt.Errorf("no valid toolchain in %s", tc.GOROOT)
+	}
+	return tc, nil
+}
+
+// check reports an error if a snippet can't be run in the playground.
+func (pg *playground) check(s *GoSnippet) error {
+	if s.Kind == SnippetMultiFiles {
+		return errors.New("code of multiple source files is not supported")
+	}
+	for _, p := range s.Imports() {
+		if !pg.std[p] {
+			return fmt.Errorf("only standard packages may be imported (%s)", p)
+		}
+	}
+	return nil
+}
+
+// run builds and runs a snippet with the toolchain in PATH. Snippets
+// without package clauses are completed as what check-snippets does.
+func (pg *playground) run(code string) (*PlayResult, error) {
+	if err := pg.init(); err != nil {
+		return nil, err
+	}
+	s := newGoSnippet("", CodeBlock{Code: code})
+	if err := pg.check(&s); err != nil {
+		return nil, err
+	}
+	return buildAndRunSnippet(pg.local, &s, PlayBuildTimeout)
+}
+
+var playErrorRegexp = regexp.MustCompile(`(?m)^(?:\./)?main\.go:(\d+)`)
+
+// buildAndRunSnippet builds a snippet with a toolchain in a temp module,
+// with the network disabled for the go command, then runs the program
+// with the playground limits.
+func buildAndRunSnippet(tc Toolchain, s *GoSnippet, buildTimeout time.Duration) (*PlayResult, error) {
+	dir, err := newScratchModule(tc.LangVersion)
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	src, headerLines := s.Source()
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte(src), 0644); err != nil {
+		return nil, err
+	}
+	return buildAndRun(tc, dir, ".", headerLines, buildTimeout)
+}
+
+// buildAndRun builds the main.go file in dir (target is "." if dir is a
+// module, or "main.go" otherwise) and runs the program. The lines in
+// compilation errors are shifted by -headerLines.
+func buildAndRun(tc Toolchain, dir, target string, headerLines int, buildTimeout time.Duration) (*PlayResult, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), buildTimeout)
+	defer cancel()
+	build := exec.CommandContext(ctx, tc.GoCmd, "build", "-o", "prog", target)
+	build.Dir, build.Env = dir, tc.environ()
+	if output, err := build.CombinedOutput(); err != nil {
+		// Make the lines in error messages relative to the snippet.
+		errs := playErrorRegexp.ReplaceAllStringFunc(string(output), func(s string) string {
+			n, _ := strconv.Atoi(playErrorRegexp.FindStringSubmatch(s)[1])
+			if n -= headerLines; n < 1 {
+				n = 1
+			}
+			return "main.go:" + strconv.Itoa(n)
+		})
+		if ctx.Err() == context.DeadlineExceeded {
+			errs += "\n[build timed out]"
+		}
+		errs = strings.TrimPrefix(strings.TrimPrefix(errs, "# snippets\n"), "# command-line-arguments\n")
+		return &PlayResult{Errors: errs, ExitCode: -1}, nil
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), PlayRunTimeout)
+	defer cancel()
+	output := &limitedBuffer{limit: MaxPlayOutputSize, onFull: cancel}
+	cmd := sandboxCommand(ctx, dir, filepath.Join(dir, "prog"))
+	cmd.Stdout, cmd.Stderr = output, output
+	err := cmd.Run()
+	result := &PlayResult{
+		Output:    output.buf.String(),
+		TimedOut:  ctx.Err() == context.DeadlineExceeded,
+		Truncated: output.full,
+	}
+	if cmd.ProcessState != nil {
+		result.ExitCode = cmd.ProcessState.ExitCode()
+		result.Status = cmd.ProcessState.String()
+	} else if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// A limitedBuffer discards the data written after it is full.
+type limitedBuffer struct {
+	buf    bytes.Buffer
+	limit  int
+	full   bool
+	onFull func()
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := b.limit - b.buf.Len(); len(p) > room {
+		b.buf.Write(p[:room])
+		if !b.full {
+			b.full = true
+			b.onFull()
+		}
+		return len(p), nil
+	}
+	return b.buf.Write(p)
+}
+
+// ServePlay serves "/play/status", which reports whether or not the
+// client may run code and the versions of the discovered toolchains,
+// "/play/run", which runs the code posted as JSON {"code": "..."} and
+// responds a PlayResult, "/play/matrix", which starts a job running the
+// posted code with all the toolchains and responds {"id": "..."}, and
+// "/play/matrix/{id}", which responds the results of the job so far:
+// {"outputs": [VersionOutput...], "total": 5, "done": false}.
+func (go101 *Go101) ServePlay(w http.ResponseWriter, r *http.Request, item string) {
+	pg := go101.playground
+	if pg == nil || !pg.allows(r) {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+
+	var response any
+	switch item {
+	default:
+		id := strings.TrimPrefix(item, "matrix/")
+		progress, ok := pg.matrixProgress(id)
+		if id == item || !ok {
+			http.NotFound(w, r)
+			return
+		}
+		response = progress
+	case "status":
+		versions := []string{}
+		select {
+		case <-pg.ready:
+			for _, tc := range pg.toolchains {
+				versions = append(versions, tc.Version)
+			}
+		default: // still initializing
+		}
+		response = map[string]any{"toolchains": versions}
+	case "run", "matrix":
+		if r.Method != http.MethodPost {
+			http.Error(w, "POST only", http.StatusMethodNotAllowed)
+			return
+		}
+		if !isSameOriginJSONRequest(r) {
+			http.Error(w, "only same-origin JSON requests are allowed", http.StatusForbidden)
+			return
+		}
+
+		var req struct {
+			Code string `json:"code"`
+		}
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, MaxPlayCodeSize))
+		if err == nil {
+			err = json.Unmarshal(body, &req)
+		}
+		if err != nil || strings.TrimSpace(req.Code) == "" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+
+		busy := func() {
+			w.Header().Set("Retry-After", "1")
+			http.Error(w, errPlaygroundBusy.Error(), http.StatusServiceUnavailable)
+		}
+		if item == "matrix" {
+			id, err := pg.startMatrix(req.Code)
+			if err == errPlaygroundBusy {
+				busy()
+				return
+			} else if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			response = map[string]any{"id": id}
+			break
+		}
+
+		select {
+		case pg.slots <- struct{}{}:
+			defer func() { <-pg.slots }()
+		default:
+			busy()
+			return
+		}
+		result, err := pg.run(req.Code)
+		if err != nil {
+			result = &PlayResult{Errors: err.Error(), ExitCode: -1}
+		}
+		response = result
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,24 @@
+//go:build !windows
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+)
+
+// sandboxCommand returns the command to run a playground program.
+// The CPU time and the data segment size of the program are limited
+// with the ulimit shell command.
+func sandboxCommand(ctx context.Context, dir, exe string) *exec.Cmd {
+	limits := fmt.Sprintf(`ulimit -t %d && ulimit -d %d && exec "$0"`, PlayCPUSeconds, PlayMemoryLimit>>10)
+	cmd := exec.CommandContext(ctx, "/bin/sh", "-c", limits, exe)
+	cmd.Dir = dir
+	cmd.Env = []string{
+		"HOME=" + dir,
+		"TMPDIR=" + dir,
+		"GOMAXPROCS=2",
+	}
+	return cmd
+}
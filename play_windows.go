@@ -0,0 +1,21 @@
+package main
+
+import (
+	"context"
+	"os/exec"
+	"strconv"
+)
+
+// sandboxCommand returns the command to run a playground program.
+// Only the memory used by Go is limited on Windows.
+func sandboxCommand(ctx context.Context, dir, exe string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, exe)
+	cmd.Dir = dir
+	cmd.Env = []string{
+		"TMP=" + dir,
+		"TEMP=" + dir,
+		"GOMAXPROCS=2",
+		"GOMEMLIMIT=" + strconv.Itoa(PlayMemoryLimit),
+	}
+	return cmd
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+const (
+	DefaultQuizSessionSize = 5
+	MaxQuizAnswersSize     = 16 << 10
+)
+
+// An APIQuiz is a quiz served by the quiz API. The answer fields are
+// blank in quiz sessions, in which the answers are checked by the server.
+type APIQuiz struct {
+	ID          string   `json:"id"`
+	Title       string   `json:"title"`
+	URL         string   `json:"url"`
+	Question    string   `json:"question"`
+	Code        string   `json:"code"`
+	Choices     []string `json:"choices"`
+	Correct     *int     `json:"correct,omitempty"` // index of the correct choice
+	Answer      string   `json:"answer,omitempty"`
+	Explanation string   `json:"explanation,omitempty"` // HTML
+}
+
+func (q *APIQuiz) withoutAnswer() APIQuiz {
+	c := *q
+	c.Correct, c.Answer, c.Explanation = nil, "", ""
+	return c
+}
+
+// A QuizSessionAnswer is a submitted answer in a quiz session.
+type QuizSessionAnswer struct {
+	ID     string `json:"id"`
+	Choice int    `json:"choice"` // 0-based, -1 means not answered
+}
+
+type QuizSessionResult struct {
+	QuizSessionAnswer
+	Correct     int    `json:"correct"`
+	Right       bool   `json:"right"`
+	Explanation string `json:"explanation,omitempty"`
+}
+
+type QuizSessionScore struct {
+	Score   int                 `json:"score"`
+	Total   int                 `json:"total"`
+	Results []QuizSessionResult `json:"results"`
+}
+
+// apiQuizzes returns the quizzes with known correct choices. The
+// correct choices of version-dependent quizzes are the ones for the
+// Go version building the server. The returned slice is shared, so
+// it must not be modified.
+func (go101 *Go101) apiQuizzes(lang string) []APIQuiz {
+	isLocal := go101.IsLocalServer()
+	if !isLocal {
+		go101.quizCacheMutex.Lock()
+		defer go101.quizCacheMutex.Unlock()
+		if quizzes, ok := go101.quizCache[lang]; ok {
+			return quizzes
+		}
+	}
+
+	quizzes := []APIQuiz{}
+	for _, quiz := range go101.loadQuizzes(lang) {
+		correct := quiz.CorrectChoice(runtime.Version())
+		if correct < 0 {
+			// Also reported by check-quizzes.
+			log.Printf("quiz %s is not served: the answer %q matches none of the choices", quiz.File, quiz.Answer)
+			continue
+		}
+		id := strings.TrimSuffix(quiz.File, ".html")
+		quizzes = append(quizzes, APIQuiz{
+			ID:          id,
+			Title:       quiz.Title,
+			URL:         langURLPrefix(lang) + "/quizzes/" + quiz.File,
+			Question:    quiz.Question,
+			Code:        quiz.Code.Code,
+			Choices:     quiz.Choices,
+			Correct:     &correct,
+			Answer:      quiz.Answer,
+			Explanation: quiz.Explanation,
+		})
+	}
+	if !isLocal {
+		if go101.quizCache == nil {
+			go101.quizCache = map[string][]APIQuiz{}
+		}
+		go101.quizCache[lang] = quizzes
+	}
+	return quizzes
+}
+
+func (go101 *Go101) clearQuizCache() {
+	go101.quizCacheMutex.Lock()
+	defer go101.quizCacheMutex.Unlock()
+	go101.quizCache = nil
+}
+
+// ServeAPI serves the JSON API:
+//
+//	GET  /api/quizzes              all quizzes
+//	GET  /api/quizzes/{id}         a quiz
+//	GET  /api/quizzes/random       a random quiz
+//	GET  /api/quizzes/session?n=5  n random quizzes without answers
+//	POST /api/quizzes/session      check the answers of a session:
+//	                               {"answers": [{"id": ..., "choice": 0}]}
+//
+// the aggregate choices of quizzes (see serveQuizChoices) and the reader data of the local user (see ServeReaderAPI).
+func (go101 *Go101) ServeAPI(w http.ResponseWriter, r *http.Request, lang, item string) {
+	tokens := strings.SplitN(item, "/", 2)
+	if tokens[0] == "me" {
+		go101.ServeReaderAPI(w, r, strings.Join(tokens[1:], ""))
+		return
+	}
+	if tokens[0] != "quizzes" {
+		http.NotFound(w, r)
+		return
+	}
+	quizzes := go101.apiQuizzes(lang)
+	if len(quizzes) == 0 && lang != DefaultLanguage {
+		quizzes = go101.apiQuizzes(DefaultLanguage)
+	}
+	w.Header().Set("Cache-Control", "no-cache, private, max-age=0")
+
+	var response any
+	switch id := strings.Join(tokens[1:], ""); id {
+	case "":
+		response = quizzes
+	case "random":
+		if len(quizzes) == 0 {
+			http.NotFound(w, r)
+			return
+		}
+		response = quizzes[rand.Intn(len(quizzes))]
+	case "session":
+		if r.Method == http.MethodPost {
+			score, ok := checkQuizSession(w, r, quizzes)
+			if !ok {
+				return
+			}
+			response = score
+			break
+		}
+		n, err := strconv.Atoi(r.URL.Query().Get("n"))
+		if err != nil || n <= 0 {
+			n = DefaultQuizSessionSize
+		}
+		if n > len(quizzes) {
+			n = len(quizzes)
+		}
+		session := make([]APIQuiz, n)
+		for i, k := range rand.Perm(len(quizzes))[:n] {
+			session[i] = quizzes[k].withoutAnswer()
+		}
+		response = map[string]any{"quizzes": session}
+	default:
+		id, sub, _ := strings.Cut(id, "/")
+		i := 0
+		for i < len(quizzes) && quizzes[i].ID != id {
+			i++
+		}
+		if i == len(quizzes) || sub != "" && sub != "choices" {
+			http.NotFound(w, r)
+			return
+		}
+		response = quizzes[i]
+		if sub == "choices" {
+			var ok bool
+			if response, ok = go101.serveQuizChoices(w, r, &quizzes[i]); !ok {
+				return
+			}
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// checkQuizSession scores the answers posted for a quiz session.
+func checkQuizSession(w http.ResponseWriter, r *http.Request, quizzes []APIQuiz) (*QuizSessionScore, bool) {
+	var req struct {
+		Answers []QuizSessionAnswer `json:"answers"`
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, MaxQuizAnswersSize))
+	if err == nil {
+		err = json.Unmarshal(body, &req)
+	}
+	if err != nil || len(req.Answers) == 0 || len(req.Answers) > len(quizzes) {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return nil, false
+	}
+
+	byID := make(map[string]*APIQuiz, len(quizzes))
+	for i := range quizzes {
+		byID[quizzes[i].ID] = &quizzes[i]
+	}
+	score := &QuizSessionScore{Total: len(req.Answers)}
+	for _, a := range req.Answers {
+		quiz := byID[a.ID]
+		if quiz == nil {
+			http.Error(w, "unknown or duplicate quiz: "+a.ID, http.StatusBadRequest)
+			return nil, false
+		}
+		delete(byID, a.ID)
+		result := QuizSessionResult{
+			QuizSessionAnswer: a,
+			Correct:           *quiz.Correct,
+			Right:             a.Choice == *quiz.Correct,
+			Explanation:       quiz.Explanation,
+		}
+		if result.Right {
+			score.Score++
+		}
+		score.Results = append(score.Results, result)
+	}
+	return score, true
+}
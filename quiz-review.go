@@ -0,0 +1,239 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// A QuizRecord is the attempts of a quiz and its review schedule,
+// which is maintained with the SM-2 algorithm.
+type QuizRecord struct {
+	Attempts    []QuizAttempt `json:"attempts"`
+	Repetitions int           `json:"repetitions"` // successive correct reviews
+	Interval    int           `json:"interval"`    // in days
+	EaseFactor  float64       `json:"easeFactor"`
+	Due         time.Time     `json:"due"`
+}
+
+type QuizAttempt struct {
+	Time   time.Time `json:"time"`
+	Choice int       `json:"choice"`
+	Right  bool      `json:"right"`
+}
+
+func (qr *QuizRecord) NumRight() (n int) {
+	for _, a := range qr.Attempts {
+		if a.Right {
+			n++
+		}
+	}
+	return n
+}
+
+func (qr *QuizRecord) LastAttempt() QuizAttempt {
+	return qr.Attempts[len(qr.Attempts)-1]
+}
+
+// record adds an attempt and schedules the next review with SM-2.
+// A right answer is viewed as quality 4 ("correct after hesitation"),
+// and a wrong one as quality 1, which restarts the repetitions.
+func (qr *QuizRecord) record(choice int, right bool, now time.Time) {
+	qr.Attempts = append(qr.Attempts, QuizAttempt{Time: now, Choice: choice, Right: right})
+	if qr.EaseFactor == 0 {
+		qr.EaseFactor = 2.5
+	}
+	q := 1.0
+	if right {
+		q = 4
+		switch qr.Repetitions {
+		case 0:
+			qr.Interval = 1
+		case 1:
+			qr.Interval = 6
+		default:
+			qr.Interval = int(math.Round(float64(qr.Interval) * qr.EaseFactor))
+		}
+		qr.Repetitions++
+	} else {
+		qr.Repetitions, qr.Interval = 0, 1
+	}
+	qr.EaseFactor += 0.1 - (5-q)*(0.08+(5-q)*0.02)
+	if qr.EaseFactor < 1.3 {
+		qr.EaseFactor = 1.3
+	}
+	qr.Due = now.AddDate(0, 0, qr.Interval)
+}
+
+// A TopicAccuracy is the accuracy of the attempts of the quizzes of a
+// topic (a word in the ids of quizzes, see quizTopics).
+type TopicAccuracy struct {
+	Topic    string `json:"topic"`
+	Attempts int    `json:"attempts"`
+	Right    int    `json:"right"`
+}
+
+func (ta *TopicAccuracy) Percent() int { return ta.Right * 100 / ta.Attempts }
+
+// A QuizReview is the review queue and the statistics of the quizzes.
+type QuizReview struct {
+	Due     []string               `json:"due"` // ids of the quizzes to review, most overdue first
+	New     []string               `json:"new"` // ids of the quizzes never attempted
+	Topics  []TopicAccuracy        `json:"topics"`
+	Records map[string]*QuizRecord `json:"records"`
+}
+
+func newQuizReview(quizzes []APIQuiz, records map[string]*QuizRecord, now time.Time) *QuizReview {
+	review := &QuizReview{Due: []string{}, New: []string{}, Records: records}
+	topics := map[string]*TopicAccuracy{}
+	for _, quiz := range quizzes {
+		qr := records[quiz.ID]
+		if qr == nil || len(qr.Attempts) == 0 {
+			review.New = append(review.New, quiz.ID)
+			continue
+		}
+		if !qr.Due.After(now) {
+			review.Due = append(review.Due, quiz.ID)
+		}
+		for _, topic := range quizTopics(quiz.ID) {
+			ta := topics[topic]
+			if ta == nil {
+				ta = &TopicAccuracy{Topic: topic}
+				topics[topic] = ta
+			}
+			ta.Attempts += len(qr.Attempts)
+			ta.Right += qr.NumRight()
+		}
+	}
+	sort.SliceStable(review.Due, func(i, j int) bool {
+		return records[review.Due[i]].Due.Before(records[review.Due[j]].Due)
+	})
+	for _, ta := range topics {
+		review.Topics = append(review.Topics, *ta)
+	}
+	sort.Slice(review.Topics, func(i, j int) bool {
+		return review.Topics[i].Topic < review.Topics[j].Topic
+	})
+	return review
+}
+
+// ServeQuizReviewAPI serves the quiz review data of the local user:
+//
+//	GET  /api/me/review       the QuizReview
+//	POST /api/me/review/{id}  record an attempt: {"choice": 0}
+func (go101 *Go101) ServeQuizReviewAPI(w http.ResponseWriter, r *http.Request, id string) {
+	rs := go101.reader
+	quizzes := go101.apiQuizzes(DefaultLanguage)
+	now := time.Now().UTC().Truncate(time.Second)
+	if id == "" {
+		if r.Method != http.MethodGet {
+			http.Error(w, "GET only", http.StatusMethodNotAllowed)
+			return
+		}
+		data, err := rs.snapshot()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, newQuizReview(quizzes, data.Quizzes, now))
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "POST only", http.StatusMethodNotAllowed)
+		return
+	}
+	var quiz *APIQuiz
+	for i := range quizzes {
+		if quizzes[i].ID == id {
+			quiz = &quizzes[i]
+		}
+	}
+	if quiz == nil {
+		http.NotFound(w, r)
+		return
+	}
+	var req struct {
+		Choice *int `json:"choice"`
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, MaxReaderRequestSize))
+	if err == nil {
+		err = json.Unmarshal(body, &req)
+	}
+	if err != nil || req.Choice == nil || *req.Choice < 0 || *req.Choice >= len(quiz.Choices) {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+
+	rs.Lock()
+	defer rs.Unlock()
+	if err := rs.load(); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if rs.data.Quizzes == nil {
+		rs.data.Quizzes = map[string]*QuizRecord{}
+	}
+	qr := rs.data.Quizzes[id]
+	if qr == nil {
+		qr = &QuizRecord{}
+		rs.data.Quizzes[id] = qr
+	}
+	right := *req.Choice == *quiz.Correct
+	qr.record(*req.Choice, right, now)
+	if err := rs.save(); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, map[string]any{
+		"right":       right,
+		"correct":     *quiz.Correct,
+		"answer":      quiz.Answer,
+		"explanation": quiz.Explanation,
+		"record":      qr,
+	})
+}
+
+// A QuizHistory is the attempts of a quiz, shown in the review page.
+type QuizHistory struct {
+	ID, Title, URL string
+	Record         *QuizRecord
+}
+
+// ServeQuizReviewPage serves "/quizzes/review", which shows the accuracy
+// by topics and the history of each quiz, and reviews the due quizzes.
+func (go101 *Go101) ServeQuizReviewPage(w http.ResponseWriter, r *http.Request, lang string) {
+	rs := go101.reader
+	if rs == nil || !isLoopbackHostRequest(r) {
+		http.NotFound(w, r)
+		return
+	}
+	data, err := rs.snapshot()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	quizzes := go101.apiQuizzes(DefaultLanguage)
+	review := newQuizReview(quizzes, data.Quizzes, time.Now().UTC())
+	var history []QuizHistory
+	for _, quiz := range quizzes {
+		if qr := data.Quizzes[quiz.ID]; qr != nil && len(qr.Attempts) > 0 {
+			history = append(history, QuizHistory{quiz.ID, quiz.Title, langURLPrefix(lang) + "/quizzes/" + quiz.ID + ".html", qr})
+		}
+	}
+	sort.SliceStable(history, func(i, j int) bool {
+		return history[i].Record.Due.Before(history[j].Record.Due)
+	})
+
+	var buf bytes.Buffer
+	t := retrievePageTemplate(Template_QuizReview, !go101.IsLocalServer())
+	if err := t.Execute(&buf, map[string]any{"Review": review, "History": history}); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	go101.writeLocalPage(w, r, lang, "quizzes", "review", "Quiz Review", buf.String())
+}
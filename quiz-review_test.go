@@ -0,0 +1,76 @@
+package main
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestQuizRecordSchedule(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	var qr QuizRecord
+	for i, c := range []struct {
+		right       bool
+		interval    int
+		repetitions int
+		easeFactor  float64
+	}{
+		{true, 1, 1, 2.5},
+		{true, 6, 2, 2.5},
+		{true, 15, 3, 2.5}, // round(6 * 2.5)
+		{true, 38, 4, 2.5}, // round(15 * 2.5)
+		{false, 1, 0, 1.96},
+		{true, 1, 1, 1.96},
+		{true, 6, 2, 1.96},
+		{true, 12, 3, 1.96}, // round(6 * 1.96)
+		{false, 1, 0, 1.42},
+		{false, 1, 0, 1.3}, // the floor
+		{false, 1, 0, 1.3},
+		{true, 1, 1, 1.3},
+	} {
+		qr.record(0, c.right, now)
+		if qr.Interval != c.interval || qr.Repetitions != c.repetitions || math.Abs(qr.EaseFactor-c.easeFactor) > 1e-9 {
+			t.Fatalf("attempt %d (right: %v): got interval %d, repetitions %d and EF %.2f, want %d, %d and %.2f",
+				i, c.right, qr.Interval, qr.Repetitions, qr.EaseFactor, c.interval, c.repetitions, c.easeFactor)
+		}
+		if want := now.AddDate(0, 0, c.interval); !qr.Due.Equal(want) {
+			t.Fatalf("attempt %d: due at %v, want %v", i, qr.Due, want)
+		}
+		now = qr.Due
+	}
+	if len(qr.Attempts) != 12 || qr.NumRight() != 8 {
+		t.Errorf("got %d attempts and %d right ones, want 12 and 8", len(qr.Attempts), qr.NumRight())
+	}
+}
+
+func TestNewQuizReview(t *testing.T) {
+	now := time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC)
+	record := func(due time.Time, rights ...bool) *QuizRecord {
+		qr := &QuizRecord{Due: due}
+		for _, right := range rights {
+			qr.Attempts = append(qr.Attempts, QuizAttempt{Right: right})
+		}
+		return qr
+	}
+	quizzes := []APIQuiz{{ID: "defer-1"}, {ID: "defer-2"}, {ID: "map-1"}, {ID: "map-2"}, {ID: "slice-1"}}
+	records := map[string]*QuizRecord{
+		"defer-1": record(now.AddDate(0, 0, -1), true, false),
+		"defer-2": record(now, true),                    // due just now
+		"map-1":   record(now.AddDate(0, 0, -5), false), // the most overdue
+		"map-2":   record(now.AddDate(0, 0, 1), true),   // not due yet
+		"slice-1": {},                                   // no attempts
+	}
+	review := newQuizReview(quizzes, records, now)
+
+	if want := []string{"map-1", "defer-1", "defer-2"}; !reflect.DeepEqual(review.Due, want) {
+		t.Errorf("got due quizzes %v, want %v", review.Due, want)
+	}
+	if want := []string{"slice-1"}; !reflect.DeepEqual(review.New, want) {
+		t.Errorf("got new quizzes %v, want %v", review.New, want)
+	}
+	want := []TopicAccuracy{{"defer", 3, 2}, {"map", 2, 1}}
+	if !reflect.DeepEqual(review.Topics, want) {
+		t.Errorf("got topics %+v, want %+v", review.Topics, want)
+	}
+}
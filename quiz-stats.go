@@ -0,0 +1,308 @@
+package main
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	QuizStatsCompactInterval = time.Hour
+	MaxQuizChoiceRequestSize = 256
+	MaxQuizPicksPerDay       = 1 << 20 // the picks over it are not counted
+)
+
+// A quizStats is the aggregate counts of the choices picked in quiz
+// pages. Nothing about the readers is recorded. Each pick is appended
+// to the stats file as an "id choice 1" line, and the file is compacted
+// periodically to one "id choice count" line per picked choice.
+//
+// Only the first pick of a quiz from a client IP in a day is counted.
+// To tell the repeated ones, the hashes of the client IPs and quiz IDs
+// of the day are kept in memory only, salted with a random value which
+// is renewed daily, so that the clients can't be tracked across days.
+type quizStats struct {
+	sync.Mutex
+	path     string
+	file     *os.File // opened for appending
+	counts   map[string][]int64
+	appended int // lines appended since the last compaction
+
+	trustedProxies []*net.IPNet // see clientIP
+	day            string
+	salt           []byte
+	picked         map[[sha256.Size]byte]bool // of the day
+}
+
+// openQuizStats loads and compacts the stats file, which is created
+// if it doesn't exist.
+func openQuizStats(path string, trustedProxies []*net.IPNet) (*quizStats, error) {
+	counts, err := readQuizStatsFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	qs := &quizStats{path: path, counts: counts, trustedProxies: trustedProxies}
+	qs.Lock()
+	defer qs.Unlock()
+	if err := qs.compact(); err != nil {
+		return nil, err
+	}
+	return qs, nil
+}
+
+// readQuizStatsFile sums the counts in a stats file. Malformed lines,
+// such as a partly written last line, are ignored.
+func readQuizStatsFile(path string) (map[string][]int64, error) {
+	counts := map[string][]int64{}
+	f, err := os.Open(path)
+	if err != nil {
+		return counts, err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 3 {
+			continue
+		}
+		choice, err1 := strconv.Atoi(fields[1])
+		n, err2 := strconv.ParseInt(fields[2], 10, 64)
+		if err1 != nil || err2 != nil || choice < 0 || choice >= 26 || n <= 0 {
+			continue
+		}
+		c := counts[fields[0]]
+		for len(c) <= choice {
+			c = append(c, 0)
+		}
+		c[choice] += n
+		counts[fields[0]] = c
+	}
+	return counts, scanner.Err()
+}
+
+// compact rewrites the stats file with the summed counts.
+// It must be called with qs locked.
+func (qs *quizStats) compact() error {
+	ids := make([]string, 0, len(qs.counts))
+	for id := range qs.counts {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	var b strings.Builder
+	for _, id := range ids {
+		for choice, n := range qs.counts[id] {
+			if n > 0 {
+				fmt.Fprintf(&b, "%s %d %d\n", id, choice, n)
+			}
+		}
+	}
+
+	dir := filepath.Dir(qs.path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, "quiz-stats-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.WriteString(b.String()); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), qs.path); err != nil {
+		return err
+	}
+	if qs.file != nil {
+		qs.file.Close()
+	}
+	qs.file, err = os.OpenFile(qs.path, os.O_WRONLY|os.O_APPEND, 0600)
+	qs.appended = 0
+	return err
+}
+
+// run compacts the stats file periodically.
+func (qs *quizStats) run(interval time.Duration) {
+	for range time.Tick(interval) {
+		qs.Lock()
+		if qs.appended > 0 {
+			if err := qs.compact(); err != nil {
+				log.Println("compact quiz stats:", err)
+			}
+		}
+		qs.Unlock()
+	}
+}
+
+func (qs *quizStats) Close() error {
+	qs.Lock()
+	defer qs.Unlock()
+	if qs.appended > 0 {
+		if err := qs.compact(); err != nil {
+			return err
+		}
+	}
+	return qs.file.Close()
+}
+
+// isFirstPick reports whether or not the pick of a quiz is the first
+// one from the client today. It must be called with qs locked.
+func (qs *quizStats) isFirstPick(id, client string, now time.Time) bool {
+	if day := now.UTC().Format("2006-01-02"); day != qs.day || qs.salt == nil {
+		salt := make([]byte, 16)
+		if _, err := rand.Read(salt); err != nil {
+			log.Println("quiz stats salt:", err)
+			return false
+		}
+		qs.day, qs.salt, qs.picked = day, salt, map[[sha256.Size]byte]bool{}
+	}
+	if len(qs.picked) >= MaxQuizPicksPerDay {
+		return false
+	}
+	h := sha256.New()
+	h.Write(qs.salt)
+	io.WriteString(h, client+" "+id)
+	var key [sha256.Size]byte
+	h.Sum(key[:0])
+	if qs.picked[key] {
+		return false
+	}
+	qs.picked[key] = true
+	return true
+}
+
+// add records a pick of a client if it is the first one of the quiz
+// from the client today, and returns the counts of the choices.
+func (qs *quizStats) add(id string, choice, numChoices int, client string) []int64 {
+	qs.Lock()
+	defer qs.Unlock()
+	c := qs.counts[id]
+	for len(c) < numChoices {
+		c = append(c, 0)
+	}
+	if qs.isFirstPick(id, client, time.Now()) {
+		c[choice]++
+		if _, err := fmt.Fprintf(qs.file, "%s %d 1\n", id, choice); err != nil {
+			log.Println("record quiz stats:", err)
+		}
+		qs.appended++
+	}
+	qs.counts[id] = c
+	return append([]int64(nil), c...)
+}
+
+func (qs *quizStats) get(id string, numChoices int) []int64 {
+	qs.Lock()
+	defer qs.Unlock()
+	c := make([]int64, numChoices)
+	copy(c, qs.counts[id])
+	return c
+}
+
+// serveQuizChoices serves the aggregate choices of a quiz:
+//
+//	GET  /api/quizzes/{id}/choices  {"counts": [12, 30, 5, 1]}
+//	POST /api/quizzes/{id}/choices  record a pick: {"choice": 1}
+func (go101 *Go101) serveQuizChoices(w http.ResponseWriter, r *http.Request, quiz *APIQuiz) (response any, ok bool) {
+	qs := go101.quizStats
+	if qs == nil {
+		http.NotFound(w, r)
+		return nil, false
+	}
+	switch r.Method {
+	case http.MethodGet:
+		return map[string]any{"counts": qs.get(quiz.ID, len(quiz.Choices))}, true
+	case http.MethodPost:
+		if !isSameOriginJSONRequest(r) {
+			http.Error(w, "only same-origin JSON requests are allowed", http.StatusForbidden)
+			return nil, false
+		}
+		var req struct {
+			Choice *int `json:"choice"`
+		}
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, MaxQuizChoiceRequestSize))
+		if err == nil {
+			err = json.Unmarshal(body, &req)
+		}
+		if err != nil || req.Choice == nil || *req.Choice < 0 || *req.Choice >= len(quiz.Choices) {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return nil, false
+		}
+		return map[string]any{"counts": qs.add(quiz.ID, *req.Choice, len(quiz.Choices), clientIP(r, qs.trustedProxies))}, true
+	}
+	http.Error(w, "GET or POST only", http.StatusMethodNotAllowed)
+	return nil, false
+}
+
+func runQuizStats(args []string) int {
+	flags := flag.NewFlagSet("quiz-stats", flag.ExitOnError)
+	minFlag := flags.Int64("min", 10, "the min number of picks of the listed quizzes")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: go101 quiz-stats [-min n] stats-file\n\nFlags:\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+	counts, err := readQuizStatsFile(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	type row struct {
+		id                 string
+		total, right       int64
+		misleading, picked int64 // the most picked wrong choice
+	}
+	var rows []row
+	for _, quiz := range go101.apiQuizzes(DefaultLanguage) {
+		c := counts[quiz.ID]
+		rw := row{id: quiz.ID, misleading: -1}
+		for choice, n := range c {
+			rw.total += n
+			if choice == *quiz.Correct {
+				rw.right = n
+			} else if n > rw.picked {
+				rw.misleading, rw.picked = int64(choice), n
+			}
+		}
+		if rw.total > 0 && rw.total >= *minFlag {
+			rows = append(rows, rw)
+		}
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		return rows[i].right*rows[j].total < rows[j].right*rows[i].total
+	})
+
+	fmt.Printf("%-20s %8s %8s  %s\n", "QUIZ", "PICKS", "RIGHT", "MOST PICKED WRONG CHOICE")
+	for _, rw := range rows {
+		wrong := "-"
+		if rw.misleading >= 0 {
+			wrong = fmt.Sprintf("%c (%d%%)", 'A'+rw.misleading, rw.picked*100/rw.total)
+		}
+		fmt.Printf("%-20s %8d %7d%%  %s\n", rw.id, rw.total, rw.right*100/rw.total, wrong)
+	}
+	return 0
+}
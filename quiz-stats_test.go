@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestQuizStatsFirstPick(t *testing.T) {
+	qs := &quizStats{}
+	day1 := time.Date(2024, 1, 1, 23, 0, 0, 0, time.UTC)
+	for _, c := range []struct {
+		id, client string
+		now        time.Time
+		want       bool
+	}{
+		{"defer-1", "1.2.3.4", day1, true},
+		{"defer-1", "1.2.3.4", day1.Add(time.Minute), false},
+		{"defer-2", "1.2.3.4", day1, true},
+		{"defer-1", "5.6.7.8", day1, true},
+		{"defer-1", "1.2.3.4", day1.Add(2 * time.Hour), true}, // the next day
+		{"defer-1", "1.2.3.4", day1.Add(3 * time.Hour), false},
+	} {
+		if got := qs.isFirstPick(c.id, c.client, c.now); got != c.want {
+			t.Errorf("isFirstPick(%s, %s, %v) = %v, want %v", c.id, c.client, c.now, got, c.want)
+		}
+	}
+
+	// The salt is renewed daily, and only the picks of the day are kept.
+	salt := qs.salt
+	qs.isFirstPick("defer-1", "1.2.3.4", day1.AddDate(0, 0, 2))
+	if bytes.Equal(salt, qs.salt) || len(qs.picked) != 1 {
+		t.Errorf("the salt is not renewed (or the picks are not reset) in a new day")
+	}
+}
+
+func TestQuizStatsCompact(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "quiz-stats.log")
+	// The last line is partly written.
+	content := "defer-1 0 1\ndefer-1 2 1\nmap-1 1 3\ndefer-1 0 1\nbad line\ndefer-1 2"
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	qs, err := openQuizStats(path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "defer-1 0 2\ndefer-1 2 1\nmap-1 1 3\n"; string(data) != want {
+		t.Errorf("compacted to %q, want %q", data, want)
+	}
+
+	if got, want := qs.add("defer-1", 1, 4, "1.2.3.4"), []int64{2, 1, 1, 0}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got counts %v, want %v", got, want)
+	}
+	if got, want := qs.add("defer-1", 1, 4, "1.2.3.4"), []int64{2, 1, 1, 0}; !reflect.DeepEqual(got, want) {
+		t.Errorf("a repeated pick is counted: got counts %v, want %v", got, want)
+	}
+	if err := qs.Close(); err != nil {
+		t.Fatal(err)
+	}
+	counts, err := readQuizStatsFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string][]int64{"defer-1": {2, 1, 1}, "map-1": {0, 3}}; !reflect.DeepEqual(counts, want) {
+		t.Errorf("got counts %v after closing, want %v", counts, want)
+	}
+}
@@ -0,0 +1,336 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"go/scanner"
+	"go/token"
+	"html"
+	"io"
+	"math/rand"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ANSI escape codes used in the terminal quiz mode.
+const (
+	ansiReset   = "\x1b[0m"
+	ansiBold    = "\x1b[1m"
+	ansiDim     = "\x1b[2m"
+	ansiRed     = "\x1b[31m"
+	ansiGreen   = "\x1b[32m"
+	ansiYellow  = "\x1b[33m"
+	ansiBlue    = "\x1b[34m"
+	ansiMagenta = "\x1b[35m"
+	ansiCyan    = "\x1b[36m"
+)
+
+// A terminalPainter colors texts with ANSI escape codes if enabled.
+type terminalPainter bool
+
+// paint colors each line of s, so that line prefixes are not colored.
+func (p terminalPainter) paint(s, color string) string {
+	if !p || color == "" || s == "" {
+		return s
+	}
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = color + line + ansiReset
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func goTokenColor(tok token.Token) string {
+	switch {
+	case tok.IsKeyword():
+		return ansiMagenta
+	case tok == token.STRING || tok == token.CHAR:
+		return ansiGreen
+	case tok == token.INT || tok == token.FLOAT || tok == token.IMAG:
+		return ansiCyan
+	case tok == token.COMMENT:
+		return ansiDim
+	}
+	return ""
+}
+
+// highlightGo colors Go source code and prefixes the lines with numbers.
+// Invalid code is not colored after the first error.
+func (p terminalPainter) highlightGo(code string) string {
+	code = strings.TrimRight(code, "\n")
+	var b strings.Builder
+	fset := token.NewFileSet()
+	file := fset.AddFile("", fset.Base(), len(code))
+	var s scanner.Scanner
+	s.Init(file, []byte(code), nil, scanner.ScanComments)
+	last := 0
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF || s.ErrorCount > 0 {
+			break
+		}
+		if tok == token.SEMICOLON && lit != ";" { // automatically inserted
+			continue
+		}
+		offset := file.Offset(pos)
+		text := lit
+		if text == "" {
+			text = tok.String()
+		}
+		b.WriteString(code[last:offset])
+		b.WriteString(p.paint(text, goTokenColor(tok)))
+		last = offset + len(text)
+	}
+	b.WriteString(code[last:])
+
+	lines := strings.Split(b.String(), "\n")
+	for i, line := range lines {
+		lines[i] = p.paint(fmt.Sprintf("%3d ", i+1), ansiDim) + line
+	}
+	return strings.Join(lines, "\n")
+}
+
+// htmlToText converts the content of a quiz answer to terminal texts.
+// Code spans are colored, list items are bulleted.
+func (p terminalPainter) htmlToText(content string) string {
+	var b strings.Builder
+	var text strings.Builder // the inline text being collected
+	var lists, inPre, inCode int
+	var preIsGo bool
+	flush := func() {
+		if t := strings.TrimSpace(text.String()); t != "" {
+			if lists > 0 {
+				b.WriteString(strings.Repeat("  ", lists-1) + "* ")
+			}
+			b.WriteString(t + "\n")
+		}
+		text.Reset()
+	}
+	z := newHTMLTokenizer([]byte(content))
+	for {
+		t, ok := z.Next()
+		if !ok {
+			break
+		}
+		switch t.Type {
+		case htmlStartTagToken:
+			switch t.Data {
+			case "ul", "ol":
+				flush()
+				lists++
+			case "div", "p", "li", "pre":
+				flush()
+				if t.Data == "pre" {
+					inPre++
+				}
+			case "code":
+				if inPre > 0 {
+					preIsGo = t.HasClass("language-go")
+				} else {
+					inCode++
+				}
+			}
+		case htmlEndTagToken:
+			switch t.Data {
+			case "ul", "ol":
+				flush()
+				lists--
+			case "div", "p", "li":
+				flush()
+			case "pre":
+				inPre--
+				code := strings.TrimLeft(text.String(), "\n")
+				text.Reset()
+				if preIsGo {
+					b.WriteString("\n" + p.highlightGo(code) + "\n\n")
+				} else {
+					b.WriteString("\n" + p.paint(strings.TrimRight(code, "\n"), ansiDim) + "\n\n")
+				}
+			case "code":
+				if inPre == 0 && inCode > 0 {
+					inCode--
+				}
+			}
+		case htmlTextToken:
+			data := html.UnescapeString(t.Data)
+			switch {
+			case inPre > 0:
+				text.WriteString(data)
+			case inCode > 0:
+				text.WriteString(p.paint(data, ansiCyan))
+			default:
+				text.WriteString(spaceCollapsed(data))
+			}
+		}
+	}
+	flush()
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// spaceCollapsed collapses the white spaces in s to single spaces,
+// keeping the leading and trailing ones (collapsed).
+func spaceCollapsed(s string) string {
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		if s == "" {
+			return ""
+		}
+		return " "
+	}
+	r := strings.Join(fields, " ")
+	if strings.TrimLeft(s, " \t\r\n") != s {
+		r = " " + r
+	}
+	if strings.TrimRight(s, " \t\r\n") != s {
+		r += " "
+	}
+	return r
+}
+
+// quizTopics returns the words in the id of a quiz, such as
+// "panic" and "recover" for "panic-recover-1".
+func quizTopics(id string) []string {
+	var topics []string
+	for _, w := range strings.Split(id, "-") {
+		if w != "" && strings.Trim(w, "0123456789") != "" {
+			topics = append(topics, w)
+		}
+	}
+	return topics
+}
+
+func matchQuizTopics(id string, topics []string) bool {
+	if len(topics) == 0 {
+		return true
+	}
+	for _, w := range quizTopics(id) {
+		for _, topic := range topics {
+			if strings.EqualFold(w, topic) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func runQuiz(args []string) int {
+	flags := flag.NewFlagSet("quiz", flag.ExitOnError)
+	topicsFlag := flags.String("topics", "", "comma-separated topics, such as loop,defer,slice (default all)")
+	shuffleFlag := flags.Bool("shuffle", false, "shuffle the questions")
+	numFlag := flags.Int("n", 0, "the number of questions (default all)")
+	colorFlag := flags.String("color", "auto", "colorize the output (auto | always | never)")
+	listFlag := flags.Bool("list", false, "list the topics and the numbers of quizzes")
+	flags.Parse(args)
+
+	var quizzes []Quiz
+	var topics []string
+	counts := map[string]int{}
+	for _, topic := range strings.Split(*topicsFlag, ",") {
+		if topic = strings.TrimSpace(topic); topic != "" {
+			topics = append(topics, topic)
+		}
+	}
+	for _, quiz := range go101.loadQuizzes(DefaultLanguage) {
+		id := strings.TrimSuffix(quiz.File, ".html")
+		if quiz.CorrectChoice(runtime.Version()) < 0 {
+			continue
+		}
+		for _, topic := range quizTopics(id) {
+			counts[topic]++
+		}
+		if matchQuizTopics(id, topics) {
+			quizzes = append(quizzes, quiz)
+		}
+	}
+	if *listFlag {
+		names := make([]string, 0, len(counts))
+		for topic := range counts {
+			names = append(names, topic)
+		}
+		sort.Strings(names)
+		for _, topic := range names {
+			fmt.Printf("%-10s %d\n", topic, counts[topic])
+		}
+		return 0
+	}
+	if len(quizzes) == 0 {
+		fmt.Fprintf(os.Stderr, "no quizzes of topics %s (run \"go101 quiz -list\" to list the topics)\n", *topicsFlag)
+		return 1
+	}
+	if *shuffleFlag {
+		r := rand.New(rand.NewSource(time.Now().UnixNano()))
+		r.Shuffle(len(quizzes), func(i, j int) { quizzes[i], quizzes[j] = quizzes[j], quizzes[i] })
+	}
+	if *numFlag > 0 && *numFlag < len(quizzes) {
+		quizzes = quizzes[:*numFlag]
+	}
+
+	var p terminalPainter
+	switch *colorFlag {
+	case "always":
+		p = true
+	case "auto":
+		info, err := os.Stdout.Stat()
+		p = err == nil && info.Mode()&os.ModeCharDevice != 0 && os.Getenv("NO_COLOR") == "" && os.Getenv("TERM") != "dumb"
+	}
+	return p.quiz(quizzes, bufio.NewReader(os.Stdin), os.Stdout)
+}
+
+// quiz asks the quizzes one by one and shows the final score.
+func (p terminalPainter) quiz(quizzes []Quiz, in *bufio.Reader, out io.Writer) int {
+	score, answered := 0, 0
+Questions:
+	for i, quiz := range quizzes {
+		correct := quiz.CorrectChoice(runtime.Version())
+		fmt.Fprintf(out, "\n%s\n\n", p.paint(fmt.Sprintf("[%d/%d] %s", i+1, len(quizzes), quiz.Title), ansiBold))
+		if quiz.Question != "" {
+			fmt.Fprintf(out, "%s\n\n", quiz.Question)
+		}
+		fmt.Fprintf(out, "%s\n\n", p.highlightGo(quiz.Code.Code))
+		for k, choice := range quiz.Choices {
+			fmt.Fprintf(out, "  %s %s\n", p.paint(fmt.Sprintf("%c)", 'A'+k), ansiYellow), choice)
+		}
+
+		var choice int
+		for {
+			fmt.Fprintf(out, "\nYour choice (A-%c, s to skip, q to quit): ", 'A'+len(quiz.Choices)-1)
+			line, err := in.ReadString('\n')
+			input := strings.ToUpper(strings.TrimSpace(line))
+			switch {
+			case input == "Q" || input == "" && err != nil:
+				fmt.Fprintln(out)
+				break Questions
+			case input == "S":
+				continue Questions
+			case len(input) == 1 && 'A' <= input[0] && int(input[0]-'A') < len(quiz.Choices):
+				choice = int(input[0] - 'A')
+			default:
+				continue
+			}
+			break
+		}
+
+		answered++
+		if choice == correct {
+			score++
+			fmt.Fprintf(out, "\n%s\n", p.paint("Correct!", ansiGreen+ansiBold))
+		} else {
+			fmt.Fprintf(out, "\n%s The answer is %c) %s\n", p.paint("Wrong.", ansiRed+ansiBold), 'A'+correct, quiz.Choices[correct])
+		}
+		fmt.Fprintf(out, "%s\n", p.paint("Answer: "+quiz.Answer, ansiBlue))
+		if quiz.Explanation != "" {
+			fmt.Fprintf(out, "\n%s\n", p.htmlToText(quiz.Explanation))
+		}
+	}
+
+	if answered > 0 {
+		fmt.Fprintf(out, "\n%s\n", p.paint(fmt.Sprintf("Score: %d/%d (%d%%)", score, answered, score*100/answered), ansiBold))
+	}
+	return 0
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"html"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// A Quiz is the question program, the choices and the answer
+// of a page in the quizzes group.
+type Quiz struct {
+	File        string
+	Title       string
+	Question    string    // the text before the question program
+	Code        CodeBlock // the question program
+	Choices     []string  // the labels of choice A, B, ...
+	Answer      string    // the text after "Answer:"
+	AnswerLine  int
+	Explanation string // the HTML content after the answer line
+}
+
+// parseQuiz extracts the quiz in a quizzes article. The pages are
+// generated from .tmd files, in which the question is in a div
+// with id "question", and the answer is the first paragraph of the
+// div with id "answer".
+func parseQuiz(article Article) (Quiz, bool) {
+	quiz := Quiz{File: article.Filename, Title: article.TitleWithoutTags}
+	for _, block := range article.CodeBlocks {
+		if strings.EqualFold(block.Lang, "go") {
+			quiz.Code = block
+			break
+		}
+	}
+
+	var (
+		divs      []string // the ids of the open divs
+		section   string   // the innermost id of the open divs
+		text      strings.Builder
+		inLabel   bool
+		inPara    bool // in a paragraph of the question
+		inAns     bool
+		explStart int
+		question  []string
+	)
+	content := []byte(article.Content)
+	z := newHTMLTokenizer(content)
+	for {
+		t, ok := z.Next()
+		if !ok {
+			break
+		}
+		switch t.Type {
+		case htmlStartTagToken:
+			if t.Data == "div" {
+				id, _ := t.Attr("id")
+				divs = append(divs, id)
+				if id != "" {
+					section = id
+				}
+			}
+			switch {
+			case t.Data == "label" && section == "choices":
+				inLabel = true
+				text.Reset()
+			case t.Data == "div" && section == "question" && t.HasClass("tmd-usual"):
+				inPara = true
+				text.Reset()
+			case t.Data == "div" && section == "answer" && quiz.AnswerLine == 0 && t.HasClass("tmd-usual"):
+				inAns = true
+				quiz.AnswerLine = t.Line + 1
+				text.Reset()
+			}
+		case htmlEndTagToken:
+			switch {
+			case t.Data == "label" && inLabel:
+				inLabel = false
+				quiz.Choices = append(quiz.Choices, collapseSpaces(text.String()))
+			case t.Data == "div" && inPara:
+				inPara = false
+				question = append(question, collapseSpaces(text.String()))
+			case t.Data == "div" && inAns:
+				inAns = false
+				explStart = t.End
+				answer := collapseSpaces(text.String())
+				if i := strings.Index(answer, ":"); i >= 0 && strings.EqualFold(answer[:i], "answer") {
+					quiz.Answer = strings.TrimSpace(answer[i+1:])
+				}
+			}
+			if t.Data == "div" && len(divs) > 0 {
+				if divs[len(divs)-1] == "answer" && explStart > 0 {
+					expl := strings.TrimSpace(string(content[explStart:t.Start]))
+					quiz.Explanation = strings.TrimSpace(strings.TrimPrefix(expl, "<p></p>"))
+				}
+				divs = divs[:len(divs)-1]
+				section = ""
+				for i := len(divs) - 1; i >= 0; i-- {
+					if divs[i] != "" {
+						section = divs[i]
+						break
+					}
+				}
+			}
+		case htmlTextToken:
+			if inLabel || inPara || inAns {
+				text.WriteString(html.UnescapeString(t.Data))
+			}
+		}
+	}
+	quiz.Question = strings.Join(question, "\n")
+	return quiz, quiz.Code.Code != "" && quiz.Answer != ""
+}
+
+// loadQuizzes returns the quizzes in the quizzes group, sorted by files.
+func (go101 *Go101) loadQuizzes(lang string) []Quiz {
+	files, _ := listArticleFiles(langGroupDir(lang, "quizzes"))
+	sort.Strings(files)
+	var quizzes []Quiz
+	for _, file := range files {
+		if !strings.HasSuffix(file, ".html") || file == "101.html" {
+			continue
+		}
+		article, err := retrieveArticleContent(lang, "quizzes", file)
+		if err != nil {
+			continue
+		}
+		if quiz, ok := parseQuiz(article); ok {
+			quizzes = append(quizzes, quiz)
+		}
+	}
+	return quizzes
+}
+
+var (
+	choiceRegexp        = regexp.MustCompile(`^[A-Z]$`)
+	printsRegexp        = regexp.MustCompile(`(?i)^\(?it prints:?\s*`)
+	versionClauseRegexp = regexp.MustCompile(`(?:^|,\s*)(.+?)\s*\((before|since) Go (\d+\.\d+)\)`)
+)
+
+// ExpectedOutput returns the output of the quiz program stated in the
+// answer, for the specified Go version. Answers may be a choice letter,
+// "It prints ...", or version-dependent, such as
+// "222 (before Go 1.22), 012 (since Go 1.22)".
+func (quiz *Quiz) ExpectedOutput(goVersion string) (string, bool) {
+	answer := quiz.Answer
+	if m := versionClauseRegexp.FindAllStringSubmatch(answer, -1); m != nil {
+		answer = ""
+		for _, clause := range m {
+			since := goVersionAtLeast(goVersion, clause[3])
+			if since == (clause[2] == "since") {
+				answer = clause[1]
+			}
+		}
+		if answer == "" {
+			return "", false
+		}
+	}
+
+	if choiceRegexp.MatchString(answer) {
+		i := int(answer[0] - 'A')
+		if i >= len(quiz.Choices) {
+			return "", false
+		}
+		answer = quiz.Choices[i]
+	}
+	return stripPrints(answer), true
+}
+
+// stripPrints removes the "It prints" wording around an output.
+func stripPrints(answer string) string {
+	if strings.HasPrefix(answer, "(") {
+		answer = strings.TrimSuffix(answer, ")")
+	}
+	answer = strings.TrimSuffix(printsRegexp.ReplaceAllString(answer, ""), ".")
+	if strings.EqualFold(answer, "nothing") {
+		answer = ""
+	}
+	return answer
+}
+
+// CorrectChoice returns the index of the choice matching the answer
+// for the specified Go version, or -1 if no choices match.
+func (quiz *Quiz) CorrectChoice(goVersion string) int {
+	answer, ok := quiz.ExpectedOutput(goVersion)
+	if !ok {
+		return -1
+	}
+	answer = strings.Join(strings.Fields(answer), " ")
+	for i, choice := range quiz.Choices {
+		if strings.Join(strings.Fields(stripPrints(choice)), " ") == answer {
+			return i
+		}
+	}
+	return -1
+}
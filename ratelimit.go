@@ -0,0 +1,168 @@
+package main
+
+import (
+	"log"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const MaxRequestURILen = 512
+
+// rateLimiter limits the request rates of clients with token buckets.
+// Each client IP owns a bucket which holds at most burst tokens and
+// is refilled with rate tokens per second.
+type rateLimiter struct {
+	mu        sync.Mutex
+	rate      float64
+	burst     float64
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+
+	// Requests from these addresses are forwarded by reverse proxies.
+	// The client IPs of such requests are got from their headers.
+	trustedProxies []*net.IPNet
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func newRateLimiter(rate float64, burst int, trustedProxies string) *rateLimiter {
+	return &rateLimiter{
+		rate:           rate,
+		burst:          float64(burst),
+		buckets:        map[string]*tokenBucket{},
+		trustedProxies: parseIPNets(trustedProxies, "trusted proxy"),
+	}
+}
+
+// parseIPNets parses a comma-separated list of IPs and CIDRs.
+func parseIPNets(list, what string) []*net.IPNet {
+	var ipnets []*net.IPNet
+	for _, p := range strings.Split(list, ",") {
+		if p = strings.TrimSpace(p); p == "" {
+			continue
+		}
+		if !strings.Contains(p, "/") {
+			if strings.Contains(p, ":") {
+				p += "/128"
+			} else {
+				p += "/32"
+			}
+		}
+		_, ipnet, err := net.ParseCIDR(p)
+		if err != nil {
+			log.Fatalf("invalid %s %s: %s", what, p, err)
+		}
+		ipnets = append(ipnets, ipnet)
+	}
+	return ipnets
+}
+
+func isTrustedProxy(ip net.IP, trustedProxies []*net.IPNet) bool {
+	for _, ipnet := range trustedProxies {
+		if ipnet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// clientIP returns the IP of the client sending r. If r is sent
+// from a trusted proxy, the rightmost untrusted address in the
+// X-Forwarded-For header (or the X-Real-IP header) is used.
+func clientIP(r *http.Request, trustedProxies []*net.IPNet) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || !isTrustedProxy(ip, trustedProxies) {
+		return host
+	}
+
+	if xff := r.Header.Values("X-Forwarded-For"); len(xff) > 0 {
+		addrs := strings.Split(strings.Join(xff, ","), ",")
+		for i := len(addrs) - 1; i >= 0; i-- {
+			addr := strings.TrimSpace(addrs[i])
+			fip := net.ParseIP(addr)
+			if fip == nil {
+				break
+			}
+			host = addr
+			if !isTrustedProxy(fip, trustedProxies) {
+				break
+			}
+		}
+		return host
+	}
+	if xrip := net.ParseIP(strings.TrimSpace(r.Header.Get("X-Real-IP"))); xrip != nil {
+		return xrip.String()
+	}
+	return host
+}
+
+// allow consumes a token from the bucket of the client. If the bucket
+// is empty, the duration to wait for the next token is returned.
+func (rl *rateLimiter) allow(client string, now time.Time) (bool, time.Duration) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	if now.Sub(rl.lastSweep) > time.Minute {
+		rl.sweep(now)
+	}
+
+	b := rl.buckets[client]
+	if b == nil {
+		b = &tokenBucket{tokens: rl.burst, last: now}
+		rl.buckets[client] = b
+	} else {
+		b.tokens = math.Min(rl.burst, b.tokens+now.Sub(b.last).Seconds()*rl.rate)
+		b.last = now
+	}
+
+	if b.tokens < 1 {
+		return false, time.Duration((1 - b.tokens) / rl.rate * float64(time.Second))
+	}
+	b.tokens--
+	return true, 0
+}
+
+// sweep removes the buckets which have been refilled fully.
+func (rl *rateLimiter) sweep(now time.Time) {
+	rl.lastSweep = now
+	for client, b := range rl.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*rl.rate >= rl.burst {
+			delete(rl.buckets, client)
+		}
+	}
+}
+
+// withRequestLimits wraps h to reject requests with overlong URLs
+// and requests from clients exceeding their rate limits.
+// A nil rl means no rate limits.
+func withRequestLimits(h http.Handler, rl *rateLimiter) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.RequestURI) > MaxRequestURILen {
+			http.Error(w, "request URI too long", http.StatusRequestURITooLong)
+			return
+		}
+
+		if rl != nil {
+			if ok, wait := rl.allow(clientIP(r, rl.trustedProxies), time.Now()); !ok {
+				secs := int(math.Ceil(wait.Seconds()))
+				w.Header().Set("Retry-After", strconv.Itoa(secs))
+				http.Error(w, "too many requests", http.StatusTooManyRequests)
+				return
+			}
+		}
+
+		h.ServeHTTP(w, r)
+	})
+}
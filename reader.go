@@ -0,0 +1,448 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"html/template"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The limits of the reader data of each article.
+const (
+	MaxReaderRequestSize   = 16 << 10
+	MaxNoteSize            = 8 << 10
+	MaxNotesPerArticle     = 200
+	MaxBookmarksPerArticle = 200
+	ReadFinishedRatio      = 0.95 // an article is finished if it is read to here
+)
+
+// ReaderData is the reading progress, bookmarks and notes of the user
+// of the local server. It is stored in the user config directory and
+// only served to the requests from the local machine.
+type ReaderData struct {
+	Articles map[string]*ArticleReading `json:"articles"` // keyed by "group/file.html"
+	Quizzes  map[string]*QuizRecord     `json:"quizzes,omitempty"`
+}
+
+type ArticleReading struct {
+	Title     string     `json:"title"`
+	Progress  float64    `json:"progress"` // the max scroll ratio, 0-1
+	Position  float64    `json:"position"` // the last scroll ratio
+	Updated   time.Time  `json:"updated"`
+	Bookmarks []Bookmark `json:"bookmarks,omitempty"`
+	Notes     []Note     `json:"notes,omitempty"`
+}
+
+func (a *ArticleReading) Finished() bool { return a.Progress >= ReadFinishedRatio }
+func (a *ArticleReading) Percent() int   { return int(a.Progress * 100) }
+
+// A Bookmark is at a heading anchor.
+type Bookmark struct {
+	Anchor string    `json:"anchor"`
+	Title  string    `json:"title"` // the heading text
+	Added  time.Time `json:"added"`
+}
+
+type Note struct {
+	ID      int64     `json:"id"`
+	Anchor  string    `json:"anchor,omitempty"` // the nearest heading, optional
+	Text    string    `json:"text"`
+	Created time.Time `json:"created"`
+	Updated time.Time `json:"updated"`
+}
+
+// A readerStore keeps the reader data in a JSON file.
+type readerStore struct {
+	sync.Mutex
+	path   string
+	data   ReaderData
+	loaded bool
+}
+
+// newReaderStore returns a store in the user config directory,
+// or nil if the directory is unknown.
+func newReaderStore() *readerStore {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		log.Printf("reader data are disabled: %s", err)
+		return nil
+	}
+	return &readerStore{path: filepath.Join(dir, "go101", "reader.json")}
+}
+
+// load must be called with the store locked.
+func (rs *readerStore) load() error {
+	if rs.loaded {
+		return nil
+	}
+	data, err := os.ReadFile(rs.path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, &rs.data); err != nil {
+			return err
+		}
+	}
+	if rs.data.Articles == nil {
+		rs.data.Articles = map[string]*ArticleReading{}
+	}
+	rs.loaded = true
+	return nil
+}
+
+// save must be called with the store locked. The file is replaced
+// atomically, so that it is never left half written.
+func (rs *readerStore) save() error {
+	data, err := json.MarshalIndent(&rs.data, "", "\t")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(rs.path), 0700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(rs.path), "reader-*.json")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), rs.path)
+}
+
+// snapshot returns a deep copy of the reader data.
+func (rs *readerStore) snapshot() (ReaderData, error) {
+	rs.Lock()
+	defer rs.Unlock()
+	var data ReaderData
+	if err := rs.load(); err != nil {
+		return data, err
+	}
+	b, err := json.Marshal(&rs.data)
+	if err == nil {
+		err = json.Unmarshal(b, &data)
+	}
+	return data, err
+}
+
+// ServeReaderAPI serves the reader data of an article ("/api/me/group/file.html")
+// and the modifications of them:
+//
+//	GET    /api/me                                  all data
+//	GET    /api/me/{group}/{file}                   the data of an article
+//	PUT    /api/me/{group}/{file}/progress          {"position": 0.5}
+//	PUT    /api/me/{group}/{file}/bookmarks         {"anchor": "...", "title": "..."}
+//	DELETE /api/me/{group}/{file}/bookmarks         {"anchor": "..."}
+//	POST   /api/me/{group}/{file}/notes             {"anchor": "...", "text": "..."}
+//	PUT    /api/me/{group}/{file}/notes/{id}        {"text": "..."}
+//	DELETE /api/me/{group}/{file}/notes/{id}
+//
+// and the quiz review data (see ServeQuizReviewAPI).
+func (go101 *Go101) ServeReaderAPI(w http.ResponseWriter, r *http.Request, path string) {
+	rs := go101.reader
+	if rs == nil || !isLoopbackHostRequest(r) {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	if r.Method != http.MethodGet && !isSameOriginJSONRequest(r) {
+		http.Error(w, "only same-origin JSON requests are allowed", http.StatusForbidden)
+		return
+	}
+
+	if path == "review" || strings.HasPrefix(path, "review/") {
+		go101.ServeQuizReviewAPI(w, r, strings.TrimPrefix(strings.TrimPrefix(path, "review"), "/"))
+		return
+	}
+	if path == "" {
+		if r.Method != http.MethodGet {
+			http.Error(w, "GET only", http.StatusMethodNotAllowed)
+			return
+		}
+		data, err := rs.snapshot()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, data)
+		return
+	}
+
+	tokens := strings.SplitN(path, "/", 4)
+	if len(tokens) < 2 || go101.pageGroups[tokens[0]] == nil ||
+		!strings.HasSuffix(tokens[1], ".html") || !articleFileExists(tokens[0], tokens[1]) {
+		http.NotFound(w, r)
+		return
+	}
+	group, file := tokens[0], tokens[1]
+	key := group + "/" + file
+
+	var req struct {
+		Position *float64 `json:"position"`
+		Title    string   `json:"title"`
+		Anchor   string   `json:"anchor"`
+		Text     string   `json:"text"`
+	}
+	if r.Method == http.MethodPut || r.Method == http.MethodPost || r.Method == http.MethodDelete {
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, MaxReaderRequestSize))
+		if err == nil && (len(body) > 0 || r.Method != http.MethodDelete) {
+			err = json.Unmarshal(body, &req)
+		}
+		if err != nil {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+	}
+
+	rs.Lock()
+	defer rs.Unlock()
+	if err := rs.load(); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	reading := rs.data.Articles[key]
+	if reading == nil {
+		reading = &ArticleReading{}
+	}
+	if r.Method == http.MethodGet && len(tokens) == 2 {
+		writeJSON(w, reading)
+		return
+	}
+
+	var response any = reading
+	now := time.Now().UTC().Truncate(time.Second)
+	badRequest := func(msg string) {
+		http.Error(w, msg, http.StatusBadRequest)
+	}
+	switch {
+	default:
+		http.Error(w, "unsupported method or path", http.StatusMethodNotAllowed)
+		return
+	case r.Method == http.MethodPut && len(tokens) == 3 && tokens[2] == "progress":
+		if req.Position == nil || *req.Position < 0 || *req.Position > 1 {
+			badRequest("position must be in [0, 1]")
+			return
+		}
+		reading.Position = *req.Position
+		if reading.Position > reading.Progress {
+			reading.Progress = reading.Position
+		}
+	case len(tokens) == 3 && tokens[2] == "bookmarks":
+		// The anchors are not in the paths, for they may contain
+		// non-ASCII letters (see isValidPathChar).
+		anchor := req.Anchor
+		if anchor == "" {
+			badRequest("no anchor")
+			return
+		}
+		i := 0
+		for i < len(reading.Bookmarks) && reading.Bookmarks[i].Anchor != anchor {
+			i++
+		}
+		switch r.Method {
+		default:
+			http.Error(w, "PUT or DELETE only", http.StatusMethodNotAllowed)
+			return
+		case http.MethodPut:
+			if i == len(reading.Bookmarks) {
+				if i >= MaxBookmarksPerArticle {
+					badRequest("too many bookmarks")
+					return
+				}
+				reading.Bookmarks = append(reading.Bookmarks, Bookmark{Anchor: anchor, Added: now})
+			}
+			reading.Bookmarks[i].Title = collapseSpaces(req.Title)
+		case http.MethodDelete:
+			if i < len(reading.Bookmarks) {
+				reading.Bookmarks = append(reading.Bookmarks[:i], reading.Bookmarks[i+1:]...)
+			}
+		}
+	case r.Method == http.MethodPost && len(tokens) == 3 && tokens[2] == "notes":
+		text := strings.TrimSpace(req.Text)
+		if text == "" || len(text) > MaxNoteSize {
+			badRequest("the note is blank or too long")
+			return
+		}
+		if len(reading.Notes) >= MaxNotesPerArticle {
+			badRequest("too many notes")
+			return
+		}
+		note := Note{ID: 1, Anchor: req.Anchor, Text: text, Created: now, Updated: now}
+		for _, n := range reading.Notes {
+			if n.ID >= note.ID {
+				note.ID = n.ID + 1
+			}
+		}
+		reading.Notes = append(reading.Notes, note)
+		response = note
+	case len(tokens) == 4 && tokens[2] == "notes":
+		id, _ := strconv.ParseInt(tokens[3], 10, 64)
+		i := 0
+		for i < len(reading.Notes) && reading.Notes[i].ID != id {
+			i++
+		}
+		if i == len(reading.Notes) {
+			http.NotFound(w, r)
+			return
+		}
+		switch r.Method {
+		default:
+			http.Error(w, "PUT or DELETE only", http.StatusMethodNotAllowed)
+			return
+		case http.MethodPut:
+			text := strings.TrimSpace(req.Text)
+			if text == "" || len(text) > MaxNoteSize {
+				badRequest("the note is blank or too long")
+				return
+			}
+			reading.Notes[i].Text, reading.Notes[i].Updated = text, now
+			response = reading.Notes[i]
+		case http.MethodDelete:
+			reading.Notes = append(reading.Notes[:i], reading.Notes[i+1:]...)
+		}
+	}
+
+	if reading.Title == "" {
+		if article, err := retrieveArticleContent(DefaultLanguage, group, file); err == nil {
+			reading.Title = article.TitleWithoutTags
+		}
+	}
+	reading.Updated = now
+	rs.data.Articles[key] = reading
+	if err := rs.save(); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, response)
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+// A ReaderGroup is the reading status of the chapters of a book
+// (or the articles of a group), shown in the "/me" page.
+type ReaderGroup struct {
+	Group, Title string
+	Unfinished   []ReaderArticle
+	NumFinished  int
+	NumChapters  int
+}
+
+type ReaderArticle struct {
+	URL, Title string
+	Reading    *ArticleReading
+}
+
+// ServeMePage serves the "/me/" page, which lists the unfinished chapters
+// by groups, and the bookmarks and notes. It is never cached.
+func (go101 *Go101) ServeMePage(w http.ResponseWriter, r *http.Request, lang, item string) {
+	rs := go101.reader
+	if rs == nil || !isLoopbackHostRequest(r) || item != "" {
+		http.NotFound(w, r)
+		return
+	}
+	data, err := rs.snapshot()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	var groups []ReaderGroup
+	listed := map[string]bool{}
+	for _, tg := range topicGroups {
+		pg := go101.indexPageGroup(lang, tg.Group)
+		rg := ReaderGroup{Group: tg.Group, Title: tg.Title, NumChapters: len(pg.chapters)}
+		for _, c := range pg.chapters {
+			key := tg.Group + "/" + c.File
+			listed[key] = true
+			if reading := data.Articles[key]; reading != nil && reading.Progress > 0 {
+				if reading.Finished() {
+					rg.NumFinished++
+				} else {
+					rg.Unfinished = append(rg.Unfinished, ReaderArticle{
+						URL:     langURLPrefix(lang) + articlePath(tg.Group, c.File),
+						Title:   c.Title,
+						Reading: reading,
+					})
+				}
+			}
+		}
+		if rg.NumFinished > 0 || len(rg.Unfinished) > 0 {
+			groups = append(groups, rg)
+		}
+	}
+
+	// The articles with bookmarks or notes, and the unfinished articles
+	// which are not listed in book indexes, most recently read first.
+	var others []ReaderArticle
+	for key, reading := range data.Articles {
+		if len(reading.Bookmarks) == 0 && len(reading.Notes) == 0 && (listed[key] || reading.Finished()) {
+			continue
+		}
+		group, file, _ := strings.Cut(key, "/")
+		others = append(others, ReaderArticle{
+			URL:     langURLPrefix(lang) + articlePath(group, file),
+			Title:   reading.Title,
+			Reading: reading,
+		})
+	}
+	sort.Slice(others, func(i, j int) bool {
+		return others[i].Reading.Updated.After(others[j].Reading.Updated)
+	})
+
+	var buf bytes.Buffer
+	t := retrievePageTemplate(Template_Me, !go101.IsLocalServer())
+	if err := t.Execute(&buf, map[string]any{"Groups": groups, "Articles": others}); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	go101.writeLocalPage(w, r, lang, "me", "index.html", "Reading Progress", buf.String())
+}
+
+// writeLocalPage writes a page of the user data, which is never cached.
+func (go101 *Go101) writeLocalPage(w http.ResponseWriter, r *http.Request, lang, group, file, title, content string) {
+	article := Article{
+		Content:            template.HTML(content),
+		Title:              template.HTML(template.HTMLEscapeString(title)),
+		TitleWithoutTags:   title,
+		Group:              group,
+		Filename:           file,
+		FilenameWithoutExt: strings.TrimSuffix(file, ".html"),
+		Language:           lang,
+		LangPrefix:         langURLPrefix(lang),
+	}
+	pageParams := map[string]any{
+		"Article":   article,
+		"Title":     title,
+		"CSPNonce":  cspNoncePlaceholder,
+		"Theme":     go101.theme,
+		"GoVersion": runtime.Version(),
+	}
+	go101.setFeatureParams(pageParams)
+	var buf bytes.Buffer
+	if err := retrievePageTemplate(Template_Article, !go101.IsLocalServer()).Execute(&buf, pageParams); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	writePage(w, r, buf.Bytes())
+}
@@ -15,13 +15,13 @@ var redirectPages = map[[2]string][2]string{
 	{"fundamentals", "quizzes.html"}:                  {"quizzes", "101.html"},
 }
 
-func (go101 *Go101) RedirectArticlePage(w http.ResponseWriter, r *http.Request, group, file string) bool {
+func (go101 *Go101) RedirectArticlePage(w http.ResponseWriter, r *http.Request, lang, group, file string) bool {
 	redirectPage, ok := redirectPages[[2]string{group, file}]
 	if ok {
-		page, isLocal := go101.articlePages.Get(group, file), go101.IsLocalServer()
+		page, isLocal := go101.articlePages.Get(langGroupDir(lang, group), file), go101.IsLocalServer()
 		if page == nil {
 			pageParams := map[string]any{
-				"RedirectPage": "/" + redirectPage[0] + "/" + redirectPage[1],
+				"RedirectPage": langURLPrefix(lang) + articlePath(redirectPage[0], redirectPage[1]),
 				//"IsLocalServer": isLocal,
 
 				//"Value": func() func(string, ...interface{}) interface{} {
@@ -45,12 +45,12 @@ func (go101 *Go101) RedirectArticlePage(w http.ResponseWriter, r *http.Request,
 			}
 
 			if !isLocal {
-				go101.articlePages.Set(group, file, page)
+				go101.articlePages.Set(langGroupDir(lang, group), file, page)
 			}
 		}
 
 		if len(page) == 0 { // blank page means page not found.
-			log.Printf("article page %s/%s is not found", group, file)
+			log.Printf("article page %s/%s is not found", langGroupDir(lang, group), file)
 			//w.Header().Set("Cache-Control", "no-cache, private, max-age=0")
 			http.Redirect(w, r, "/article/101.html", http.StatusNotFound)
 		} else if isLocal {
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Rendered pages are cached as fixed bytes, so the template engine
+// outputs this placeholder in place of the nonce of inline scripts.
+// The placeholder is replaced with a per-request nonce when a page
+// is written to a response.
+const cspNoncePlaceholder = "go101-csp-nonce-placeholder"
+
+var contentSecurityPolicy = strings.Join([]string{
+	"default-src 'self'",
+	"script-src 'self' 'nonce-%s'",
+	"style-src 'self' 'unsafe-inline'", // many pages use style attributes
+	"img-src 'self' data:",
+	"object-src 'none'",
+	"base-uri 'self'",
+	"form-action 'self'",
+	"frame-ancestors 'self'",
+}, "; ")
+
+var permissionsPolicy = strings.Join([]string{
+	"camera=()",
+	"microphone=()",
+	"geolocation=()",
+	"payment=()",
+	"usb=()",
+}, ", ")
+
+type cspNonceKey struct{}
+
+func newCSPNonce() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic("generate CSP nonce error: " + err.Error())
+	}
+	return base64.StdEncoding.EncodeToString(b[:])
+}
+
+// withSecurityHeaders wraps h to set security related response headers
+// for every request. The CSP nonce generated for a request is passed
+// to h through the request context.
+func withSecurityHeaders(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		nonce := newCSPNonce()
+		header := w.Header()
+		header.Set("Content-Security-Policy", fmt.Sprintf(contentSecurityPolicy, nonce))
+		header.Set("X-Content-Type-Options", "nosniff")
+		header.Set("Referrer-Policy", "strict-origin-when-cross-origin")
+		header.Set("Permissions-Policy", permissionsPolicy)
+		if r.TLS != nil {
+			header.Set("Strict-Transport-Security", "max-age=31536000; includeSubDomains")
+		}
+		h.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), cspNonceKey{}, nonce)))
+	})
+}
+
+func requestCSPNonce(r *http.Request) string {
+	nonce, _ := r.Context().Value(cspNonceKey{}).(string)
+	return nonce
+}
+
+// writePage writes a rendered page, with the CSP nonce
+// placeholders in it replaced with the nonce of the request.
+func writePage(w http.ResponseWriter, r *http.Request, page []byte) {
+	w.Write(bytes.ReplaceAll(page, []byte(cspNoncePlaceholder), []byte(requestCSPNonce(r))))
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A VersionOutput is the result of running a snippet with a toolchain.
+type VersionOutput struct {
+	Version string      `json:"version"`
+	Result  *PlayResult `json:"result"`
+	Changed bool        `json:"changed"` // differs from the result of the previous version
+}
+
+// runSnippetMatrix runs a snippet with each of the toolchains (sorted by
+// versions) one by one. The results are in the order of the toolchains.
+// If progress is not nil, it is called with the results so far after
+// each toolchain is done.
+func runSnippetMatrix(toolchains []Toolchain, s *GoSnippet, buildTimeout time.Duration, progress func([]VersionOutput)) []VersionOutput {
+	outputs := make([]VersionOutput, 0, len(toolchains))
+	for i, tc := range toolchains {
+		result, err := buildAndRunSnippet(tc, s, buildTimeout)
+		if err != nil {
+			result = &PlayResult{Errors: err.Error(), ExitCode: -1}
+		}
+		o := VersionOutput{Version: tc.Version, Result: result}
+		if i > 0 {
+			a := outputs[i-1].Result
+			o.Changed = a.Output != result.Output || a.Errors != result.Errors || a.Status != result.Status
+		}
+		outputs = append(outputs, o)
+		if progress != nil {
+			progress(outputs)
+		}
+	}
+	return outputs
+}
+
+// Running a snippet with all the toolchains takes longer than the write
+// timeout of the server, so it is run as a job in the background, and
+// the results are polled by the page.
+const (
+	MaxMatrixJobs = 16
+	MatrixJobTTL  = 10 * time.Minute // after done
+)
+
+type matrixJob struct {
+	outputs []VersionOutput // of the toolchains run so far
+	total   int
+	done    time.Time // zero if running
+}
+
+// startMatrix starts a job running a snippet with all the discovered
+// toolchains and returns the job ID. A playground slot is held by the
+// job until it is done.
+func (pg *playground) startMatrix(code string) (string, error) {
+	if err := pg.init(); err != nil {
+		return "", err
+	}
+	s := newGoSnippet("", CodeBlock{Code: code})
+	if err := pg.check(&s); err != nil {
+		return "", err
+	}
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	id := hex.EncodeToString(b)
+
+	pg.jobsMu.Lock()
+	defer pg.jobsMu.Unlock()
+	for id, job := range pg.jobs {
+		if !job.done.IsZero() && time.Since(job.done) > MatrixJobTTL {
+			delete(pg.jobs, id)
+		}
+	}
+	if len(pg.jobs) >= MaxMatrixJobs {
+		return "", errPlaygroundBusy
+	}
+	select {
+	case pg.slots <- struct{}{}:
+	default:
+		return "", errPlaygroundBusy
+	}
+	if pg.jobs == nil {
+		pg.jobs = map[string]*matrixJob{}
+	}
+	job := &matrixJob{total: len(pg.toolchains)}
+	pg.jobs[id] = job
+
+	go func() {
+		defer func() { <-pg.slots }()
+		runSnippetMatrix(pg.toolchains, &s, PlayBuildTimeout, func(outputs []VersionOutput) {
+			pg.jobsMu.Lock()
+			defer pg.jobsMu.Unlock()
+			job.outputs = append([]VersionOutput(nil), outputs...)
+		})
+		pg.jobsMu.Lock()
+		defer pg.jobsMu.Unlock()
+		job.done = time.Now()
+	}()
+	return id, nil
+}
+
+// matrixProgress returns the results so far of a job.
+func (pg *playground) matrixProgress(id string) (map[string]any, bool) {
+	pg.jobsMu.Lock()
+	defer pg.jobsMu.Unlock()
+	job := pg.jobs[id]
+	if job == nil {
+		return nil, false
+	}
+	outputs := job.outputs
+	if outputs == nil {
+		outputs = []VersionOutput{}
+	}
+	return map[string]any{"outputs": outputs, "total": job.total, "done": !job.done.IsZero()}, true
+}
+
+// findPageSnippet finds the Go snippet at a position like
+// "pages/bugs/xxx.html:123" (as reported by check-snippets).
+func (go101 *Go101) findPageSnippet(pos string) (*GoSnippet, error) {
+	pos = strings.TrimPrefix(pos, "pages/")
+	i := strings.LastIndexByte(pos, ':')
+	if i < 0 {
+		return nil, errors.New("no line number in " + pos)
+	}
+	line, err := strconv.Atoi(pos[i+1:])
+	if err != nil {
+		return nil, fmt.Errorf("invalid line number in %s", pos)
+	}
+	page := pos[:i]
+	for _, s := range go101.collectGoSnippets([]string{path.Dir(page)}) {
+		if s.Page == page && s.Line-1 <= line && line < s.Line+strings.Count(s.Code, "\n") {
+			return &s, nil
+		}
+	}
+	return nil, fmt.Errorf("no Go code at %s", pos)
+}
+
+func runToolchainMatrix(args []string) int {
+	flags := flag.NewFlagSet("toolchain-matrix", flag.ExitOnError)
+	gorootsFlag := flags.String("goroots", "", "comma-separated GOROOTs (besides the ones cached by gotv)")
+	timeoutFlag := flags.Duration("timeout", time.Minute, "the time limit of building the snippet with each toolchain")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: go101 toolchain-matrix [flags] (file.go | - | pages/group/file.html:line)\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+
+	var s *GoSnippet
+	switch arg := flags.Arg(0); {
+	case strings.Contains(arg, ".html:"):
+		var err error
+		if s, err = go101.findPageSnippet(arg); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+	default:
+		var code []byte
+		var err error
+		if arg == "-" {
+			code, err = io.ReadAll(os.Stdin)
+		} else {
+			code, err = os.ReadFile(arg)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		snippet := newGoSnippet(arg, CodeBlock{Code: string(code), CodeLine: 1})
+		s = &snippet
+	}
+
+	toolchains := discoverToolchains(*gorootsFlag)
+	if len(toolchains) == 0 {
+		fmt.Fprintln(os.Stderr, "no toolchains found")
+		return 1
+	}
+	outputs := runSnippetMatrix(toolchains, s, *timeoutFlag, nil)
+
+	var changes []string
+	for _, o := range outputs {
+		mark := ""
+		if o.Changed {
+			mark = " (changed)"
+			changes = append(changes, o.Version)
+		}
+		r := o.Result
+		status := r.Status
+		switch {
+		case r.Errors != "":
+			status = "compilation failed"
+		case r.TimedOut:
+			status = "timed out"
+		}
+		fmt.Printf("== %s%s [%s]\n", o.Version, mark, status)
+		text := r.Output
+		if r.Errors != "" {
+			text = r.Errors
+		}
+		for _, line := range strings.Split(strings.TrimRight(text, "\n"), "\n") {
+			fmt.Printf("\t%s\n", line)
+		}
+	}
+	if len(changes) == 0 {
+		fmt.Printf("%d toolchains, same results\n", len(outputs))
+	} else {
+		fmt.Printf("%d toolchains, results changed in %s\n", len(outputs), strings.Join(changes, ", "))
+	}
+	return 0
+}
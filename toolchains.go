@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A Toolchain is a locally installed Go toolchain.
+type Toolchain struct {
+	Version     string // such as go1.22.1
+	LangVersion string // such as 1.22, used in go.mod files
+	GOROOT      string
+	GoCmd       string
+}
+
+func (tc *Toolchain) environ() []string {
+	env := os.Environ()
+	if tc.GOROOT != "" {
+		env = append(env, "GOROOT="+tc.GOROOT)
+	}
+	return append(env, "GOPROXY=off", "GOTOOLCHAIN=local", "GOFLAGS=-mod=mod", "CGO_ENABLED=0")
+}
+
+var toolchainVersionRegexp = regexp.MustCompile(`go(\d+)\.(\d+)(?:\.(\d+))?((?:rc|beta)\d+)?`)
+
+// newToolchain returns the toolchain in a GOROOT. The version of the
+// toolchain is read from the VERSION file in the GOROOT, or is got
+// with the go command if the file doesn't exist (in development trees).
+func newToolchain(goroot string) (Toolchain, bool) {
+	tc := Toolchain{GOROOT: goroot, GoCmd: filepath.Join(goroot, "bin", "go")}
+	if runtime.GOOS == "windows" {
+		tc.GoCmd += ".exe"
+	}
+	if info, err := os.Stat(tc.GoCmd); err != nil || info.IsDir() {
+		return tc, false
+	}
+	version, err := os.ReadFile(filepath.Join(goroot, "VERSION"))
+	if err != nil {
+		if version, err = runShellCommand(time.Minute/2, "", tc.GoCmd, "env", "GOVERSION"); err != nil {
+			return tc, false
+		}
+	}
+	tc.Version = string(bytes.TrimSpace(bytes.SplitN(version, []byte("\n"), 2)[0]))
+	m := toolchainVersionRegexp.FindStringSubmatch(tc.Version)
+	if m == nil {
+		return tc, false
+	}
+	tc.LangVersion = m[1] + "." + m[2]
+	return tc, true
+}
+
+// selectToolchain returns the toolchain in goroot, or the one of the go
+// command in PATH if goroot is blank.
+func selectToolchain(goroot string) (Toolchain, error) {
+	if goroot == "" {
+		return localToolchain()
+	}
+	tc, ok := newToolchain(goroot)
+	if !ok {
+		return tc, fmt.Errorf("no valid toolchain in %s", goroot)
+	}
+	return tc, nil
+}
+
+// gotvCacheDir returns the folder in which gotv caches toolchains,
+// such as ~/.cache/gotv on Linux and ~/Library/Caches/gotv on macOS.
+func gotvCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "gotv")
+}
+
+// discoverToolchains returns the toolchains in the specified GOROOTs
+// (comma-separated), the ones cached by gotv and the one of the go
+// command in PATH. Toolchains of the same version are listed once.
+// The returned toolchains are sorted by versions.
+func discoverToolchains(goroots string) []Toolchain {
+	var dirs []string
+	for _, dir := range strings.Split(goroots, ",") {
+		if dir = strings.TrimSpace(dir); dir != "" {
+			dirs = append(dirs, dir)
+		}
+	}
+	if cache := gotvCacheDir(); cache != "" {
+		entries, _ := os.ReadDir(cache)
+		for _, e := range entries {
+			dirs = append(dirs, filepath.Join(cache, e.Name())) // may be symlinks
+		}
+	}
+	if output, err := runShellCommand(time.Minute/2, "", "go", "env", "GOROOT"); err == nil {
+		dirs = append(dirs, string(bytes.TrimSpace(output)))
+	}
+
+	var toolchains []Toolchain
+	versions := map[string]bool{}
+	for _, dir := range dirs {
+		if tc, ok := newToolchain(dir); ok && !versions[tc.Version] {
+			versions[tc.Version] = true
+			toolchains = append(toolchains, tc)
+		}
+	}
+	sort.Slice(toolchains, func(i, j int) bool {
+		return compareToolchainVersions(toolchains[i].Version, toolchains[j].Version) < 0
+	})
+	return toolchains
+}
+
+// compareToolchainVersions compares versions like go1.21.0, go1.22rc1
+// and "devel go1.23-abcdef". Development versions are the newest ones
+// of their language versions.
+func compareToolchainVersions(a, b string) int {
+	parse := func(v string) [5]int {
+		var n [5]int
+		m := toolchainVersionRegexp.FindStringSubmatch(v)
+		if m == nil {
+			return n
+		}
+		n[0], _ = strconv.Atoi(m[1])
+		n[1], _ = strconv.Atoi(m[2])
+		n[2], _ = strconv.Atoi(m[3])
+		switch {
+		case strings.HasPrefix(v, "devel"):
+			n[3] = 3
+		case strings.HasPrefix(m[4], "rc"):
+			n[3] = 1
+			n[4], _ = strconv.Atoi(m[4][2:])
+		case strings.HasPrefix(m[4], "beta"):
+			n[4], _ = strconv.Atoi(m[4][4:])
+		default:
+			n[3] = 2
+		}
+		return n
+	}
+	va, vb := parse(a), parse(b)
+	for i := range va {
+		if va[i] != vb[i] {
+			if va[i] < vb[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return strings.Compare(a, b)
+}
+
+func (tc Toolchain) String() string {
+	return fmt.Sprintf("%s (%s)", tc.Version, tc.GOROOT)
+}
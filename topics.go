@@ -0,0 +1,282 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"html/template"
+	"io/fs"
+	"log"
+	"net/http"
+	"runtime"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// The groups listed in topic pages, in order.
+var topicGroups = []struct {
+	Group, Title string
+}{
+	{"fundamentals", "Go (Fundamentals) 101"},
+	{"generics", "Go Generics 101"},
+	{"details-and-tips", "Go Details & Tips 101"},
+	{"optimizations", "Go Optimizations 101"},
+	{"quizzes", "Go Quizzes 101"},
+	{"q-and-a", "Go Q&A 101"},
+	{"bugs", "Go Bugs 101"},
+	{"blog", "Go 101 Blog"},
+	{"apps-and-libs", "Go 101 Apps & Libs"},
+}
+
+// Articles without "tags" metadata are tagged by matching these keywords
+// with the whole words (or word sequences) in their file names and titles.
+var topicKeywords = map[string][]string{
+	"channels":     {"channel", "channels"},
+	"concurrency":  {"concurrency", "concurrent", "concurrently", "goroutine", "goroutines", "synchronization", "synchronize", "atomic", "atomics", "mutex", "mutexes", "memory model"},
+	"constants":    {"const", "constant", "constants", "iota"},
+	"defer":        {"defer", "deferred"},
+	"panics":       {"panic", "panics", "recover", "exception", "exceptions"},
+	"generics":     {"generic", "generics", "type parameter", "type parameters", "type constraint", "type constraints", "type argument", "type arguments"},
+	"interfaces":   {"interface", "interfaces"},
+	"iterators":    {"iterator", "iterators", "ranging over"},
+	"loops":        {"loop", "loops", "for-range", "for range"},
+	"maps":         {"map", "maps"},
+	"memory":       {"memory", "allocation", "allocations", "value part", "value parts", "value copy", "value copies"},
+	"methods":      {"method", "methods"},
+	"pointers":     {"pointer", "pointers", "unsafe"},
+	"slices":       {"slice", "slices", "array", "arrays", "container", "containers"},
+	"strings":      {"string", "strings"},
+	"structs":      {"struct", "structs", "embedding"},
+	"bce":          {"bce", "bounds check", "bounds checks"},
+	"reflection":   {"reflect", "reflection"},
+	"scopes":       {"scope", "scopes", "block", "blocks", "package-level variable", "package-level variables"},
+	"toolchain":    {"toolchain", "toolchains", "go build", "go version", "go.mod", "sdk", "gotv", "gold", "golds"},
+	"functions":    {"function", "functions", "call", "calls"},
+	"operators":    {"operator", "operators", "expression", "expressions", "evaluation order"},
+	"json":         {"json"},
+	"control flow": {"control flow", "control flows", "switch", "goto"},
+}
+
+type Topic struct {
+	Name     string
+	Slug     string // used in URLs
+	Articles []TopicArticle
+	Size     float64 // font size in tag clouds, in em
+}
+
+type TopicArticle struct {
+	Group, GroupTitle string
+	URL, Title        string
+}
+
+// topicSlug returns the file name (without extension) of a topic page.
+// Blank is returned if the topic can't be used in URLs.
+func topicSlug(topic string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(topic) {
+		switch {
+		case 'a' <= r && r <= 'z', '0' <= r && r <= '9':
+			b.WriteRune(r)
+		case r == '-' || unicode.IsSpace(r):
+			if b.Len() > 0 && !strings.HasSuffix(b.String(), "-") {
+				b.WriteByte('-')
+			}
+		}
+	}
+	return strings.TrimSuffix(b.String(), "-")
+}
+
+// articleTopics returns the topics of an article. Its tags are used
+// if it has ones, otherwise the topics are guessed by keywords.
+func articleTopics(article *Article) []string {
+	if len(article.Meta.Tags) > 0 {
+		return article.Meta.Tags
+	}
+
+	text := strings.TrimSuffix(article.Filename, ".html") + " " + article.TitleWithoutTags
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '.' && r != '-'
+	})
+	for i, w := range words {
+		words[i] = strings.Trim(w, ".-")
+	}
+	// Both "for-range" and "for range" are matched.
+	normalized := " " + strings.Join(words, " ") + " " + strings.ReplaceAll(strings.Join(words, " "), "-", " ") + " "
+
+	var topics []string
+	for topic, keywords := range topicKeywords {
+		for _, kw := range keywords {
+			if strings.Contains(normalized, " "+kw+" ") {
+				topics = append(topics, topic)
+				break
+			}
+		}
+	}
+	sort.Strings(topics)
+	return topics
+}
+
+// isListedInTopics reports whether or not an article is listed in topic
+// pages. Book indexes and update logs are not.
+func isListedInTopics(file string) bool {
+	return file != "101.html" && !strings.HasPrefix(file, "100-") &&
+		!strings.Contains(file, "acknowledgements")
+}
+
+// collectTopics returns all topics of the articles in the specified
+// language, sorted by names. Untranslated articles are listed with
+// their English titles.
+func (go101 *Go101) collectTopics(lang string) []Topic {
+	topics := map[string]*Topic{}
+	for _, g := range topicGroups {
+		if go101.pageGroups[g.Group] == nil {
+			continue
+		}
+		files, err := listArticleFiles(g.Group)
+		if err != nil {
+			log.Printf("list files of group %s error: %s", g.Group, err)
+			continue
+		}
+		sort.Strings(files)
+		for _, file := range files {
+			if !strings.HasSuffix(file, ".html") || !isListedInTopics(file) {
+				continue
+			}
+			if _, redirected := redirectPages[[2]string{g.Group, file}]; redirected {
+				continue
+			}
+			article, err := retrieveArticleContent(lang, g.Group, file)
+			if lang != DefaultLanguage && errors.Is(err, fs.ErrNotExist) {
+				article, err = retrieveArticleContent(DefaultLanguage, g.Group, file)
+			}
+			if err != nil {
+				log.Printf("load article %s/%s error: %s", g.Group, file, err)
+				continue
+			}
+			if article.TitleWithoutTags == "" { // such as "Moved to" stubs
+				continue
+			}
+			article.Meta = loadArticleMeta(DefaultLanguage, g.Group, file)
+
+			for _, name := range articleTopics(&article) {
+				slug := topicSlug(name)
+				if slug == "" {
+					continue
+				}
+				t := topics[slug]
+				if t == nil {
+					t = &Topic{Name: name, Slug: slug}
+					topics[slug] = t
+				}
+				t.Articles = append(t.Articles, TopicArticle{
+					Group:      g.Group,
+					GroupTitle: g.Title,
+					URL:        langURLPrefix(lang) + articlePath(g.Group, file),
+					Title:      article.TitleWithoutTags,
+				})
+			}
+		}
+	}
+
+	list := make([]Topic, 0, len(topics))
+	minN, maxN := -1, 0
+	for _, t := range topics {
+		list = append(list, *t)
+		if n := len(t.Articles); minN < 0 || n < minN {
+			minN = n
+		}
+		if n := len(t.Articles); n > maxN {
+			maxN = n
+		}
+	}
+	for i := range list {
+		list[i].Size = 1
+		if maxN > minN {
+			list[i].Size = 0.8 + 0.9*float64(len(list[i].Articles)-minN)/float64(maxN-minN)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Slug < list[j].Slug
+	})
+	return list
+}
+
+// ServeTopicPage serves the topic index page (item is blank)
+// and the pages of topics (item is <topic-slug>.html).
+func (go101 *Go101) ServeTopicPage(w http.ResponseWriter, r *http.Request, lang, item string) {
+	file := item
+	if file == "" {
+		file = "index.html"
+	}
+
+	page, isLocal := go101.articlePages.Get(langGroupDir(lang, "topics"), file), go101.IsLocalServer()
+	if page == nil {
+		page = go101.renderTopicPage(lang, file, isLocal)
+		if !isLocal {
+			go101.articlePages.Set(langGroupDir(lang, "topics"), file, page)
+		}
+	}
+
+	if len(page) == 0 {
+		log.Printf("topic page %s is not found", item)
+		http.Redirect(w, r, "/", http.StatusNotFound)
+		return
+	}
+
+	if isLocal {
+		w.Header().Set("Cache-Control", "no-cache, private, max-age=0")
+	} else {
+		w.Header().Set("Cache-Control", "max-age=50000") // about 14 hours
+	}
+	writePage(w, r, page)
+}
+
+// renderTopicPage returns a blank page if the topic doesn't exist.
+func (go101 *Go101) renderTopicPage(lang, file string, isLocal bool) []byte {
+	topics := go101.collectTopics(lang)
+	params := map[string]any{"LangPrefix": langURLPrefix(lang)}
+	title := "Topics"
+	if file == "index.html" {
+		params["Topics"] = topics
+	} else {
+		slug := strings.TrimSuffix(file, ".html")
+		i := sort.Search(len(topics), func(i int) bool { return topics[i].Slug >= slug })
+		if i == len(topics) || topics[i].Slug != slug || slug+".html" != file {
+			return []byte{}
+		}
+		params["Topic"] = topics[i]
+		title = "Topic: " + topics[i].Name
+	}
+
+	var buf bytes.Buffer
+	t := retrievePageTemplate(Template_Topics, !isLocal)
+	if err := t.Execute(&buf, params); err != nil {
+		return []byte(err.Error())
+	}
+
+	article := Article{
+		Content:            template.HTML(buf.String()),
+		Title:              template.HTML(template.HTMLEscapeString(title)),
+		TitleWithoutTags:   title,
+		Group:              "topics",
+		Filename:           file,
+		FilenameWithoutExt: strings.TrimSuffix(file, ".html"),
+		Language:           lang,
+		LangPrefix:         langURLPrefix(lang),
+	}
+	pageParams := map[string]any{
+		"Article":      article,
+		"Title":        article.TitleWithoutTags,
+		"CanonicalURL": canonicalArticleURL(lang, "topics", file),
+		"CSPNonce":     cspNoncePlaceholder,
+		"Theme":        go101.theme,
+		"GoVersion":    runtime.Version(),
+	}
+	go101.setFeatureParams(pageParams)
+	buf.Reset()
+	t = retrievePageTemplate(Template_Article, !isLocal)
+	if err := t.Execute(&buf, pageParams); err != nil {
+		return []byte(err.Error())
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,147 @@
+
+// Run/Edit buttons for Go code blocks. They are only shown if the
+// local server allows the client to run code (see play.go).
+
+$(document).ready(function(){
+	var blocks = $('pre > code.language-go')
+	if (blocks.length == 0 || !window.fetch) {
+		return
+	}
+
+	var post = function(path, code) {
+		return fetch(path, {
+			method: 'POST',
+			headers: {'Content-Type': 'application/json'},
+			body: JSON.stringify({code: code}),
+		}).then(function(resp) {
+			if (!resp.ok) {
+				return resp.text().then(function(text) {
+					throw new Error(resp.status + ' ' + text)
+				})
+			}
+			return resp.json()
+		})
+	}
+
+	var resultText = function(result) {
+		var text = result.errors ? result.errors : result.output
+		if (result.truncated) {
+			text += '\n[output truncated]'
+		}
+		if (result.timedOut) {
+			text += '\n[timed out]'
+		} else if (!result.errors) {
+			text += '\n[' + result.status + ']'
+		}
+		return text
+	}
+
+	var run = function(code, output) {
+		output.text('Running...').show()
+		post('/play/run', code).then(function(result) {
+			output.text(resultText(result))
+		}).catch(function(err) {
+			output.text('Error: ' + err.message)
+		})
+	}
+
+	// Show the outputs of the code with each toolchain in a table.
+	// The rows with outputs different from the previous ones are marked.
+	// The code is run as a job on the server, whose results are polled.
+	var runMatrix = function(code, output) {
+		output.text('Running with all toolchains...').show()
+		var show = function(progress) {
+			var table = $('<table class="table table-sm play-matrix"></table>')
+			progress.outputs.forEach(function(o) {
+				var row = $('<tr></tr>').toggleClass('play-changed', o.changed)
+				row.append($('<th></th>').text(o.version + (o.changed ? ' *' : '')))
+				row.append($('<td></td>').append($('<pre></pre>').text(resultText(o.result))))
+				table.append(row)
+			})
+			output.empty().append(table)
+			if (!progress.done) {
+				output.append($('<p></p>').text('Running... (' + progress.outputs.length + ' of ' + progress.total + ' done)'))
+			}
+		}
+		var poll = function(id) {
+			return fetch('/play/matrix/' + id).then(function(resp) {
+				if (!resp.ok) {
+					throw new Error(resp.status + ' ' + resp.statusText)
+				}
+				return resp.json()
+			}).then(function(progress) {
+				show(progress)
+				if (!progress.done) {
+					return new Promise(function(resolve) {
+						setTimeout(resolve, 500)
+					}).then(function() {
+						return poll(id)
+					})
+				}
+			})
+		}
+		post('/play/matrix', code).then(function(job) {
+			return poll(job.id)
+		}).catch(function(err) {
+			output.text('Error: ' + err.message)
+		})
+	}
+
+	var addButtons = function(code, toolchains) {
+		var pre = $(code).parent()
+		var original = code.textContent
+		var bar = $('<div class="play-bar"></div>')
+		var output = $('<pre class="play-output"></pre>').hide()
+		var runButton = $('<button type="button" class="btn btn-sm btn-outline-secondary">Run</button>')
+		var editButton = $('<button type="button" class="btn btn-sm btn-outline-secondary">Edit</button>')
+		var resetButton = $('<button type="button" class="btn btn-sm btn-outline-secondary">Reset</button>').hide()
+
+		var matrixButton = $('<button type="button" class="btn btn-sm btn-outline-secondary"></button>')
+			.text('Run with ' + toolchains.length + ' Go versions')
+
+		runButton.click(function() {
+			run(code.textContent, output)
+		})
+		matrixButton.click(function() {
+			runMatrix(code.textContent, output)
+		})
+		editButton.click(function() {
+			if (code.getAttribute('contenteditable') == null) {
+				code.setAttribute('contenteditable', 'true')
+				code.setAttribute('spellcheck', 'false')
+				code.focus()
+				editButton.text('Done')
+				resetButton.show()
+			} else {
+				code.removeAttribute('contenteditable')
+				editButton.text('Edit')
+			}
+		})
+		resetButton.click(function() {
+			code.textContent = original
+			if (window.Prism) {
+				Prism.highlightElement(code)
+			}
+			output.hide()
+		})
+
+		bar.append(runButton, ' ', editButton, ' ', resetButton)
+		if (toolchains.length > 1) {
+			bar.prepend(matrixButton, ' ')
+		}
+		pre.after(bar)
+		bar.after(output)
+	}
+
+	fetch('/play/status').then(function(resp) {
+		if (resp.ok) {
+			return resp.json()
+		}
+	}).then(function(status) {
+		if (status) {
+			blocks.each(function() {
+				addButtons(this, status.toolchains)
+			})
+		}
+	})
+});
@@ -0,0 +1,96 @@
+
+// The quiz review at /quizzes/review (see quiz-review.go). The due
+// quizzes are asked one by one, then the new ones if requested.
+
+$(document).ready(function(){
+	var box = $('#quiz-review')
+	if (box.length == 0 || !window.fetch) {
+		return
+	}
+
+	var request = function(method, path, body) {
+		return fetch(path, {
+			method: method,
+			headers: {'Content-Type': 'application/json'},
+			body: body === undefined ? undefined : JSON.stringify(body),
+		}).then(function(resp) {
+			if (!resp.ok) {
+				return resp.text().then(function(text) {
+					throw new Error(resp.status + ' ' + text)
+				})
+			}
+			return resp.json()
+		})
+	}
+
+	var queue = [], asked = 0, right = 0, newIDs = []
+
+	var finish = function() {
+		box.empty()
+		if (asked > 0) {
+			box.append($('<p></p>').text('Reviewed ' + asked + ' quizzes, ' + right + ' answered right.'))
+		} else {
+			box.append('<p>No quizzes are due to review.</p>')
+		}
+		if (newIDs.length > 0) {
+			$('<button type="button" class="btn btn-sm btn-outline-secondary"></button>')
+				.text('Try ' + Math.min(5, newIDs.length) + ' new quizzes')
+				.click(function() {
+					queue = newIDs.splice(0, 5)
+					next()
+				}).appendTo(box)
+		}
+	}
+
+	var show = function(quiz) {
+		box.empty()
+		box.append($('<h3></h3>').append($('<a></a>').attr('href', quiz.url).text(quiz.title)))
+		if (quiz.question) {
+			box.append($('<p></p>').text(quiz.question))
+		}
+		var code = $('<code class="language-go"></code>').text(quiz.code)
+		box.append($('<pre class="line-numbers"></pre>').append(code))
+		if (window.Prism) {
+			Prism.highlightElement(code[0])
+		}
+		var choices = $('<ul style="list-style-type:none;"></ul>').appendTo(box)
+		quiz.choices.forEach(function(choice, k) {
+			$('<li></li>').append($('<a role="button"></a>').text(String.fromCharCode(65 + k) + ') ' + choice).click(function() {
+				choices.find('a').off('click')
+				request('POST', '/api/me/review/' + quiz.id, {choice: k}).then(function(result) {
+					asked++
+					if (result.right) {
+						right++
+					}
+					choices.children().eq(result.correct).css('font-weight', 'bold')
+					box.append($('<p></p>').text((result.right ? '✔ Right. ' : '✘ Wrong. ') + result.answer))
+					if (result.explanation) {
+						box.append($('<div></div>').html(result.explanation))
+					}
+					box.append($('<p><small></small></p>').children().text('Next review: ' + result.record.due.slice(0, 10)).end())
+					$('<button type="button" class="btn btn-sm btn-outline-secondary">Next</button>').click(next).appendTo(box)
+				})
+			})).appendTo(choices)
+		})
+	}
+
+	var next = function() {
+		if (queue.length == 0) {
+			finish()
+			return
+		}
+		request('GET', '/api/quizzes/' + queue.shift()).then(show)
+	}
+
+	request('GET', '/api/me/review').then(function(review) {
+		queue = review.due
+		newIDs = review.new
+		if (queue.length > 0) {
+			$('<button type="button" class="btn btn-sm btn-outline-secondary">Start reviewing</button>').click(next).appendTo(box)
+		} else {
+			finish()
+		}
+	}).catch(function() {
+		// Not served, such as by remote servers.
+	})
+});
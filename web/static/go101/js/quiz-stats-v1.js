@@ -0,0 +1,55 @@
+
+// The aggregate choices of readers in quiz pages (see quiz-stats.go).
+// A pick is only sent once per browser, and nothing else is sent.
+
+$(document).ready(function(){
+	var page = location.pathname.match(/\/quizzes\/([\w-]+)\.html$/)
+	var inputs = $('input[name=choice]')
+	if (!page || inputs.length == 0 || !window.fetch) {
+		return
+	}
+	var api = '/api/quizzes/' + page[1] + '/choices', key = 'quiz-choice:' + page[1]
+
+	var minPicks = 10 // percentages of fewer picks are not meaningful
+	var show = function(data) {
+		var total = data.counts.reduce(function(a, b) { return a + b }, 0)
+		if (total < minPicks) {
+			return
+		}
+		var texts = data.counts.map(function(n, k) {
+			return Math.round(n * 100 / total) + '% of readers chose ' + String.fromCharCode(65 + k)
+		})
+		$('#quiz-stats').remove()
+		$('<p id="quiz-stats"><small></small></p>').children().text(texts.join(', ') + '.').end().prependTo('#answer')
+	}
+
+	var picked = null
+	try {
+		picked = localStorage.getItem(key)
+	} catch (e) {}
+
+	inputs.one('change', function() {
+		inputs.off('change')
+		var req
+		if (picked == null) {
+			var choice = this.id.charCodeAt(this.id.length - 1) - 65
+			try {
+				localStorage.setItem(key, choice)
+			} catch (e) {}
+			req = fetch(api, {
+				method: 'POST',
+				headers: {'Content-Type': 'application/json'},
+				body: JSON.stringify({choice: choice}),
+			})
+		} else {
+			req = fetch(api)
+		}
+		req.then(function(resp) {
+			if (resp.ok) {
+				return resp.json().then(show)
+			}
+		}).catch(function() {
+			// Not served, such as by the generated pages.
+		})
+	})
+});
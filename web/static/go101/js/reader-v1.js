@@ -0,0 +1,173 @@
+
+// Reading progress, bookmarks and notes. They are stored by the local
+// server (see reader.go) and only shown if the server serves them.
+
+$(document).ready(function(){
+	var bar = $('#reader-bar')
+	if (bar.length == 0 || !window.fetch) {
+		return
+	}
+	var api = '/api/me/' + bar.data('page')
+
+	var request = function(method, path, body) {
+		return fetch(api + path, {
+			method: method,
+			headers: {'Content-Type': 'application/json'},
+			body: body === undefined ? undefined : JSON.stringify(body),
+		}).then(function(resp) {
+			if (!resp.ok) {
+				return resp.text().then(function(text) {
+					throw new Error(resp.status + ' ' + text)
+				})
+			}
+			return resp.json()
+		})
+	}
+
+	var headings = $('h2[id], h3[id], h4[id], h5[id], h6[id]').filter(function() {
+		return $(this).closest('.article-toc').length == 0
+	})
+
+	var headingText = function(h) {
+		return $(h).clone().find('.heading-anchor, .reader-bookmark').remove().end().text().trim()
+	}
+
+	// The heading of the section being read.
+	var currentAnchor = function() {
+		var anchor = ''
+		headings.each(function() {
+			if (this.getBoundingClientRect().top > 80) {
+				return false
+			}
+			anchor = this.id
+		})
+		return anchor
+	}
+
+	var scrollRatio = function() {
+		var height = $(document).height() - $(window).height()
+		return height > 0 ? Math.min(1, Math.max(0, $(window).scrollTop() / height)) : 1
+	}
+
+	var showBar = function(reading) {
+		bar.empty().append($('<span></span>').text('Read ' + Math.floor(reading.progress * 100) + '%'))
+		if (reading.position > 0.02 && reading.position < 0.98) {
+			$('<a>Resume</a>').click(function() {
+				var height = $(document).height() - $(window).height()
+				window.scrollTo(0, reading.position * height)
+			}).appendTo(bar)
+		}
+		bar.append($('<a href="/me/">My reading</a>'))
+		bar.prop('hidden', false)
+	}
+
+	var trackProgress = function(reading) {
+		var saved = reading.position, timer = null
+		$(window).on('scroll', function() {
+			clearTimeout(timer)
+			timer = setTimeout(function() {
+				var position = scrollRatio()
+				if (Math.abs(position - saved) < 0.01) {
+					return
+				}
+				saved = position
+				request('PUT', '/progress', {position: position}).then(showBar)
+			}, 1500)
+		})
+	}
+
+	var addBookmarkButtons = function(reading) {
+		var marked = {}
+		;(reading.bookmarks || []).forEach(function(b) {
+			marked[b.anchor] = true
+		})
+		headings.each(function() {
+			var h = this
+			var button = $('<a class="reader-bookmark" title="Bookmark">🔖</a>').toggleClass('marked', !!marked[h.id])
+			button.click(function() {
+				var req = button.hasClass('marked') ? request('DELETE', '/bookmarks', {anchor: h.id}) : request('PUT', '/bookmarks', {anchor: h.id, title: headingText(h)})
+				req.then(function() {
+					button.toggleClass('marked')
+				})
+			})
+			$(h).append(button)
+		})
+	}
+
+	// The section read before scrolling to the notes, used as
+	// the default section of new notes.
+	var notesBox = $('#reader-notes'), lastAnchor = ''
+	$(window).on('scroll', function() {
+		if (notesBox[0] && notesBox[0].getBoundingClientRect().top > $(window).height()) {
+			lastAnchor = currentAnchor()
+		}
+	})
+	var showNotes = function(notes) {
+		notesBox.empty().append('<p><b>My notes</b> <small>(stored on this machine only)</small></p>')
+		notes.forEach(function(note) {
+			var div = $('<div class="reader-note"></div>').text(note.text)
+			var info = $('<small></small>')
+			if (note.anchor) {
+				info.append(' ', $('<a></a>').attr('href', '#' + note.anchor).text('#' + note.anchor))
+			}
+			var editButton = $('<a role="button">edit</a>').click(function() {
+				var text = window.prompt('Edit the note:', note.text)
+				if (text != null && text.trim() != '') {
+					request('PUT', '/notes/' + note.id, {text: text}).then(function(updated) {
+						note.text = updated.text
+						showNotes(notes)
+					})
+				}
+			})
+			var deleteButton = $('<a role="button">delete</a>').click(function() {
+				if (window.confirm('Delete the note?')) {
+					request('DELETE', '/notes/' + note.id).then(function(reading) {
+						showNotes(reading.notes || [])
+					})
+				}
+			})
+			info.append(' ', editButton, ' ', deleteButton)
+			notesBox.append(div.append(' ', info))
+		})
+
+		var input = $('<textarea rows="3" placeholder="A new note"></textarea>')
+		var section = $('<select class="form-control-sm"><option value="">(whole article)</option></select>')
+		headings.each(function() {
+			section.append($('<option></option>').val(this.id).text(headingText(this)))
+		})
+		section.val(lastAnchor)
+		var addButton = $('<button type="button" class="btn btn-sm btn-outline-secondary">Add note</button>').click(function() {
+			var text = input.val().trim()
+			if (text != '') {
+				request('POST', '/notes', {anchor: section.val(), text: text}).then(function(note) {
+					notes.push(note)
+					showNotes(notes)
+				})
+			}
+		})
+		notesBox.append(input, section, ' ', addButton).prop('hidden', false)
+	}
+
+	// The first choice made in a quiz page is recorded for reviews.
+	var page = bar.data('page').match(/^quizzes\/(.+)\.html$/)
+	if (page) {
+		$('input[name=choice]').one('change', function() {
+			$('input[name=choice]').off('change')
+			var choice = this.id.charCodeAt(this.id.length - 1) - 65
+			fetch('/api/me/review/' + page[1], {
+				method: 'POST',
+				headers: {'Content-Type': 'application/json'},
+				body: JSON.stringify({choice: choice}),
+			})
+		})
+	}
+
+	request('GET', '').then(function(reading) {
+		showBar(reading)
+		trackProgress(reading)
+		addBookmarkButtons(reading)
+		showNotes(reading.notes || [])
+	}).catch(function() {
+		// Not served, such as by remote servers.
+	})
+});